@@ -0,0 +1,78 @@
+// Copyright 2018 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Fleet is the data structure for a Fleet resource
+type Fleet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FleetSpec   `json:"spec"`
+	Status FleetStatus `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// FleetList is a list of Fleet resources
+type FleetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []Fleet `json:"items"`
+}
+
+// FleetSpec is the spec for a Fleet
+type FleetSpec struct {
+	// Replicas the number of GameServers that should be in this set
+	Replicas int32 `json:"replicas"`
+
+	// Template the GameServer template to apply for this Fleet
+	Template GameServerTemplateSpec `json:"template"`
+}
+
+// FleetStatus is the status of a Fleet
+type FleetStatus struct {
+	// Replicas the total number of current GameServer replicas
+	Replicas int32 `json:"replicas"`
+
+	// ReadyReplicas are the number of Ready GameServer replicas
+	ReadyReplicas int32 `json:"readyReplicas"`
+
+	// ReservedReplicas are the number of Reserved GameServer replicas
+	ReservedReplicas int32 `json:"reservedReplicas"`
+
+	// AllocatedReplicas are the number of Allocated GameServer replicas
+	AllocatedReplicas int32 `json:"allocatedReplicas"`
+}
+
+// GameServerTemplateSpec is a template for GameServers
+type GameServerTemplateSpec struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              GameServerSpec `json:"spec"`
+}
+
+// GameServerSpec is the spec for a GameServer, carrying the backing Pod template
+type GameServerSpec struct {
+	// Template describes the Pod that will be created for the GameServer
+	Template corev1.PodTemplateSpec `json:"template"`
+}
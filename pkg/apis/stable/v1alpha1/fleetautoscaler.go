@@ -0,0 +1,822 @@
+// Copyright 2018 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	admregv1b "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// FleetAutoscalerPolicyType is the policy for autoscaling
+// for a given Fleet
+type FleetAutoscalerPolicyType string
+
+const (
+	// BufferPolicyType is a simple buffering strategy for Ready
+	// GameServers
+	BufferPolicyType FleetAutoscalerPolicyType = "Buffer"
+
+	// WebhookPolicyType calls an outside server to determine scaling policy
+	WebhookPolicyType FleetAutoscalerPolicyType = "Webhook"
+
+	// MetricsPolicyType scales based on resource and custom metrics collected
+	// from the GameServer pods backing the Fleet, similar to the Kubernetes
+	// HorizontalPodAutoscaler v2 API.
+	MetricsPolicyType FleetAutoscalerPolicyType = "Metrics"
+
+	// SchedulePolicyType scales according to a set of cron-based time windows, for
+	// time-of-day and event-driven prewarming.
+	SchedulePolicyType FleetAutoscalerPolicyType = "Schedule"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// FleetAutoscaler is the data structure for a FleetAutoscaler resource
+type FleetAutoscaler struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FleetAutoscalerSpec   `json:"spec"`
+	Status FleetAutoscalerStatus `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// FleetAutoscalerList is a list of Fleet Autoscaler resources
+type FleetAutoscalerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []FleetAutoscaler `json:"items"`
+}
+
+// FleetAutoscalerSpec is the spec for a Fleet Autoscaler
+type FleetAutoscalerSpec struct {
+	FleetName string `json:"fleetName"`
+	// Autoscaling policy
+	Policy FleetAutoscalerPolicy `json:"policy"`
+
+	// Behavior configures the scaling behavior of the target, to prevent flapping.
+	// If not set, scaling recommendations are applied immediately, with no rate limiting.
+	// +optional
+	Behavior *FleetAutoscalerBehavior `json:"behavior,omitempty"`
+}
+
+// FleetAutoscalerBehavior configures the scaling behavior of the target in both Up and Down
+// directions (ScaleUp and ScaleDown) mirroring Kubernetes HPA v2's HorizontalPodAutoscalerBehavior.
+type FleetAutoscalerBehavior struct {
+	// ScaleUp is scaling policy for scaling Up. If not set, the default value is to allow to
+	// scale up immediately.
+	// +optional
+	ScaleUp *FleetAutoscalerScalingRules `json:"scaleUp,omitempty"`
+
+	// ScaleDown is scaling policy for scaling Down. If not set, the default value is to allow
+	// to scale down immediately.
+	// +optional
+	ScaleDown *FleetAutoscalerScalingRules `json:"scaleDown,omitempty"`
+}
+
+// FleetAutoscalerScalingRules configures the scaling behavior for one direction via a list of
+// rate-limiting Policies, and a StabilizationWindowSeconds used to suppress flapping.
+type FleetAutoscalerScalingRules struct {
+	// StabilizationWindowSeconds is the number of seconds for which past recommendations should
+	// be considered while scaling up or down. This value must be greater than or equal to zero
+	// and less than or equal to 3600 (one hour). If not set, it defaults to 0 (i.e. no
+	// stabilization is done).
+	// +optional
+	StabilizationWindowSeconds *int32 `json:"stabilizationWindowSeconds,omitempty"`
+
+	// SelectPolicy is used to specify which policy should be used. If not set, the default
+	// value is Max.
+	// +optional
+	SelectPolicy *FleetAutoscalerSelectPolicy `json:"selectPolicy,omitempty"`
+
+	// Policies is a list of potential scaling polices which can be used during scaling. If not
+	// set, no rate limiting is applied.
+	// +optional
+	Policies []FleetAutoscalerScalingPolicy `json:"policies,omitempty"`
+}
+
+// FleetAutoscalerSelectPolicy is used to specify which policy should be used while scaling in a
+// given direction
+type FleetAutoscalerSelectPolicy string
+
+const (
+	// MaxScalingPolicySelect selects the policy with the highest possible change
+	MaxScalingPolicySelect FleetAutoscalerSelectPolicy = "Max"
+
+	// MinScalingPolicySelect selects the policy with the lowest possible change
+	MinScalingPolicySelect FleetAutoscalerSelectPolicy = "Min"
+
+	// DisabledScalingPolicySelect disables scaling in this direction entirely
+	DisabledScalingPolicySelect FleetAutoscalerSelectPolicy = "Disabled"
+)
+
+// FleetAutoscalerScalingPolicyType is the type of the policy used in a FleetAutoscalerScalingRules
+type FleetAutoscalerScalingPolicyType string
+
+const (
+	// PodsScalingPolicy is a policy used to specify a change in absolute number of replicas
+	PodsScalingPolicy FleetAutoscalerScalingPolicyType = "Pods"
+
+	// PercentScalingPolicy is a policy used to specify a relative amount of change with respect
+	// to the replica count
+	PercentScalingPolicy FleetAutoscalerScalingPolicyType = "Percent"
+)
+
+// FleetAutoscalerScalingPolicy is a single policy which must hold true for a specified past
+// interval, limiting the delta of replicas which may be applied in one scaling event.
+type FleetAutoscalerScalingPolicy struct {
+	// Type is used to specify the scaling policy: Pods or Percent
+	Type FleetAutoscalerScalingPolicyType `json:"type"`
+
+	// Value contains the amount of change which is permitted by the policy. It must be greater
+	// than zero.
+	Value int32 `json:"value"`
+
+	// PeriodSeconds specifies the window of time for which the policy should hold true. It must
+	// be greater than zero and less than or equal to 1800 (30 minutes).
+	PeriodSeconds int32 `json:"periodSeconds"`
+}
+
+// FleetAutoscalerPolicy describes how to scale a Fleet
+type FleetAutoscalerPolicy struct {
+	// Type of autoscaling policy.
+	Type FleetAutoscalerPolicyType `json:"type"`
+
+	// Buffer policy config params. Present only if Type = Buffer.
+	// +optional
+	Buffer *BufferPolicy `json:"buffer,omitempty"`
+
+	// Webhook policy config params. Present only if Type = Webhook.
+	// +optional
+	Webhook *WebhookPolicy `json:"webhook,omitempty"`
+
+	// Metrics policy config params. Present only if Type = Metrics.
+	// +optional
+	Metrics *MetricsPolicy `json:"metrics,omitempty"`
+
+	// Schedule policy config params. Present only if Type = Schedule.
+	// +optional
+	Schedule *SchedulePolicy `json:"schedule,omitempty"`
+}
+
+// SchedulePolicy controls the desired behavior of the schedule policy, allowing replica bounds
+// to change for a set of cron-based time windows, for time-of-day and event-driven prewarming.
+type SchedulePolicy struct {
+	// Entries is the list of time windows during which the Fleet should be scaled according to
+	// that entry's bounds. If more than one entry's window is active at a given time, the first
+	// matching entry in the list is used.
+	Entries []ScheduleEntry `json:"entries"`
+
+	// FallbackPolicy is the policy applied when no entry's window is currently active.
+	// +optional
+	FallbackPolicy *FleetAutoscalerPolicy `json:"fallbackPolicy,omitempty"`
+}
+
+// ScheduleEntry is a single cron-triggered time window, during which the Fleet's replica count
+// is bound by MinReplicas/MaxReplicas, with the actual recommendation coming from FallbackPolicy.
+type ScheduleEntry struct {
+	// Cron is a standard 5-field cron expression (minute hour day-of-month month day-of-week)
+	// describing when this window begins.
+	Cron string `json:"cron"`
+
+	// TimeZone is the IANA time zone name the Cron expression is evaluated in. Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Duration is how long the window stays active for, starting from each time Cron fires.
+	Duration metav1.Duration `json:"duration"`
+
+	// MinReplicas is the minimum amount of replicas that the fleet must have while this window
+	// is active.
+	MinReplicas int32 `json:"minReplicas"`
+
+	// MaxReplicas is the maximum amount of replicas that the fleet may have while this window
+	// is active.
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// FallbackPolicy is used to compute the recommendation while this window is active, before
+	// it is clamped to MinReplicas/MaxReplicas. If not set, the Fleet's current replica count is
+	// used, meaning the window only has an effect when the Fleet would otherwise be scaled
+	// outside of MinReplicas/MaxReplicas by some other means.
+	// +optional
+	FallbackPolicy *FleetAutoscalerPolicy `json:"fallbackPolicy,omitempty"`
+}
+
+// BufferPolicy controls the desired behavior of the buffer policy
+type BufferPolicy struct {
+	// MaxReplicas is the maximum amount of replicas that the fleet may have.
+	// It must be bigger than both MinReplicas and BufferSize
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// MinReplicas is the minimum amount of replicas that the fleet must have
+	MinReplicas int32 `json:"minReplicas"`
+
+	// BufferSize defines how many replicas should be always available either in
+	// absolute value (i.e. 5) or percentage format (i.e. 5%)
+	BufferSize intstr.IntOrString `json:"bufferSize"`
+}
+
+// WebhookPolicy controls the desired behavior of the webhook policy.
+// It is similar to Webhook Admission Controller configuration
+type WebhookPolicy struct {
+	// URL gives the location of the webhook, in standard URL form
+	// (`scheme://host:port/path`). Exactly one of `URL` or `Service`
+	// must be specified.
+	// +optional
+	URL *string `json:"url,omitempty"`
+
+	// Service is a reference to the service for this webhook. Either
+	// Service or URL must be specified. If the webhook is running within the
+	// cluster, then you should use `Service`.
+	// +optional
+	Service *admregv1b.ServiceReference `json:"service,omitempty"`
+
+	// CABundle is a PEM encoded CA bundle which will be used to validate the
+	// webhook's server certificate.
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+}
+
+// MetricsPolicy controls the desired behavior of metrics-driven autoscaling,
+// mirroring Kubernetes HPA v2's MetricSpec model.
+type MetricsPolicy struct {
+	// MinReplicas is the minimum amount of replicas that the fleet must have
+	MinReplicas int32 `json:"minReplicas"`
+
+	// MaxReplicas is the maximum amount of replicas that the fleet may have
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// Metrics is the list of metric sources used to compute the desired
+	// replica count. The largest recommendation from each source is used.
+	Metrics []MetricSpec `json:"metrics"`
+}
+
+// MetricSourceType indicates the type of metric a MetricSpec is drawing from
+type MetricSourceType string
+
+const (
+	// ResourceMetricSourceType is a resource metric known to Kubernetes, as
+	// specified in requests and limits, aggregated across the GameServer
+	// pods backing the Fleet.
+	ResourceMetricSourceType MetricSourceType = "Resource"
+
+	// PodsMetricSourceType is a metric describing each GameServer pod in
+	// the Fleet, averaged across all of them.
+	PodsMetricSourceType MetricSourceType = "Pods"
+
+	// ObjectMetricSourceType is a metric describing a single Kubernetes
+	// object, such as a queue length.
+	ObjectMetricSourceType MetricSourceType = "Object"
+)
+
+// MetricTargetType specifies the units for a metric target
+type MetricTargetType string
+
+const (
+	// UtilizationMetricType declares a MetricTarget is an average
+	// utilization percentage
+	UtilizationMetricType MetricTargetType = "Utilization"
+
+	// ValueMetricType declares a MetricTarget is a raw value
+	ValueMetricType MetricTargetType = "Value"
+
+	// AverageValueMetricType declares a MetricTarget is an average value
+	AverageValueMetricType MetricTargetType = "AverageValue"
+)
+
+// MetricTarget defines the target value, average value, or average
+// utilization of a specific metric
+type MetricTarget struct {
+	// Type represents whether the metric type is Utilization, Value, or
+	// AverageValue
+	Type MetricTargetType `json:"type"`
+
+	// Value is the target value of the metric, as a whole number (an
+	// integer, or a string holding an integer).
+	// +optional
+	Value *intstr.IntOrString `json:"value,omitempty"`
+
+	// AverageValue is the target value of the average of the metric across
+	// all relevant GameServer pods, as a whole number (an integer, or a
+	// string holding an integer)
+	// +optional
+	AverageValue *intstr.IntOrString `json:"averageValue,omitempty"`
+
+	// AverageUtilization is the target value of the average of the resource
+	// metric across all relevant GameServer pods, represented as a
+	// percentage of the requested value of the resource for the pods.
+	// Only valid for Resource metric source type.
+	// +optional
+	AverageUtilization *int32 `json:"averageUtilization,omitempty"`
+}
+
+// ResourceMetricSource indicates the resource metric (CPU or memory) to
+// scale on, averaged across all the GameServer pods backing the Fleet
+type ResourceMetricSource struct {
+	// Name is the name of the resource in question, i.e. "cpu" or "memory"
+	Name string `json:"name"`
+
+	// Target specifies the target value for the given metric
+	Target MetricTarget `json:"target"`
+}
+
+// PodsMetricSource indicates a metric describing each GameServer pod in the
+// Fleet, averaged across all of them (e.g. "active_sessions")
+type PodsMetricSource struct {
+	// Metric identifies the target metric by name
+	Metric string `json:"metric"`
+
+	// Target specifies the target value for the given metric
+	Target MetricTarget `json:"target"`
+}
+
+// ObjectMetricSource indicates a metric describing a single Kubernetes
+// object, i.e. the length of a matchmaking queue
+type ObjectMetricSource struct {
+	// DescribedObject specifies the object that the metric is reported for
+	DescribedObject CrossVersionObjectReference `json:"describedObject"`
+
+	// Metric identifies the target metric by name
+	Metric string `json:"metric"`
+
+	// Target specifies the target value for the given metric
+	Target MetricTarget `json:"target"`
+}
+
+// CrossVersionObjectReference contains enough information to let you
+// identify the referred resource
+type CrossVersionObjectReference struct {
+	// Kind of the referent
+	Kind string `json:"kind"`
+	// Name of the referent
+	Name string `json:"name"`
+	// API version of the referent
+	APIVersion string `json:"apiVersion,omitempty"`
+}
+
+// MetricSpec specifies how to scale based on a single metric (only `Type`
+// and one of the corresponding source fields should be set at once)
+type MetricSpec struct {
+	// Type is the type of metric source. It should be one of "Resource",
+	// "Pods" or "Object"
+	Type MetricSourceType `json:"type"`
+
+	// Resource refers to a resource metric known to Kubernetes, as
+	// specified in requests and limits, describing each GameServer pod in
+	// the Fleet. Present only if Type = Resource.
+	// +optional
+	Resource *ResourceMetricSource `json:"resource,omitempty"`
+
+	// Pods refers to a metric describing each GameServer pod in the Fleet.
+	// Present only if Type = Pods.
+	// +optional
+	Pods *PodsMetricSource `json:"pods,omitempty"`
+
+	// Object refers to a metric describing a single Kubernetes object.
+	// Present only if Type = Object.
+	// +optional
+	Object *ObjectMetricSource `json:"object,omitempty"`
+}
+
+// FleetAutoscalerStatus defines the current status of a FleetAutoscaler
+type FleetAutoscalerStatus struct {
+	// CurrentReplicas is the current number of GameServer replicas of the Fleet managed by this autoscaler,
+	// as last seen by the autoscaler.
+	CurrentReplicas int32 `json:"currentReplicas"`
+
+	// DesiredReplicas is the desired number of GameServer replicas of the Fleet managed by this autoscaler,
+	// as last calculated by the autoscaler.
+	DesiredReplicas int32 `json:"desiredReplicas"`
+
+	// lastScaleTime is the last time the FleetAutoscaler scaled the attached Fleet,
+	// +optional
+	LastScaleTime *metav1.Time `json:"lastScaleTime"`
+
+	// AbleToScale indicates that we can access the target fleet
+	AbleToScale bool `json:"ableToScale"`
+
+	// ScalingLimited indicates that the calculated scale would be above or below the range
+	// defined by MinReplicas/MaxReplicas, or that the Behavior's scaling rules held the change
+	// back, and has thus been capped.
+	ScalingLimited bool `json:"scalingLimited"`
+}
+
+// Validate validates the FleetAutoscalerSpec for this FleetAutoscaler
+func (fas *FleetAutoscaler) Validate() ([]metav1.StatusCause, bool) {
+	var causes []metav1.StatusCause
+
+	switch fas.Spec.Policy.Type {
+	case BufferPolicyType:
+		causes = append(causes, fas.Spec.Policy.Buffer.ValidateBufferPolicy()...)
+	case WebhookPolicyType:
+		causes = append(causes, fas.Spec.Policy.Webhook.ValidateWebhookPolicy()...)
+	case MetricsPolicyType:
+		causes = append(causes, fas.Spec.Policy.Metrics.ValidateMetricsPolicy()...)
+	case SchedulePolicyType:
+		causes = append(causes, fas.Spec.Policy.Schedule.ValidateSchedulePolicy()...)
+	default:
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueNotSupported,
+			Message: fmt.Sprintf("Unsupported policy type %s, should be one of: %s, %s, %s, %s", fas.Spec.Policy.Type, BufferPolicyType, WebhookPolicyType, MetricsPolicyType, SchedulePolicyType),
+			Field:   "policy.type",
+		})
+	}
+
+	causes = append(causes, fas.Spec.Behavior.ValidateBehavior()...)
+
+	return causes, len(causes) == 0
+}
+
+// ValidateBehavior validates the Behavior part of a FleetAutoscalerSpec, if set
+func (b *FleetAutoscalerBehavior) ValidateBehavior() []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	if b == nil {
+		return causes
+	}
+
+	causes = append(causes, b.ScaleUp.validateScalingRules("behavior.scaleUp")...)
+	causes = append(causes, b.ScaleDown.validateScalingRules("behavior.scaleDown")...)
+
+	return causes
+}
+
+func (r *FleetAutoscalerScalingRules) validateScalingRules(field string) []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	if r == nil {
+		return causes
+	}
+
+	if r.StabilizationWindowSeconds != nil && (*r.StabilizationWindowSeconds < 0 || *r.StabilizationWindowSeconds > 3600) {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: "stabilizationWindowSeconds must be between 0 and 3600",
+			Field:   field + ".stabilizationWindowSeconds",
+		})
+	}
+
+	for i, p := range r.Policies {
+		if p.Type != PodsScalingPolicy && p.Type != PercentScalingPolicy {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueNotSupported,
+				Message: fmt.Sprintf("Unsupported scaling policy type %s, should be one of: %s, %s", p.Type, PodsScalingPolicy, PercentScalingPolicy),
+				Field:   fmt.Sprintf("%s.policies[%d].type", field, i),
+			})
+		}
+		if p.Value <= 0 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Message: "value must be greater than 0",
+				Field:   fmt.Sprintf("%s.policies[%d].value", field, i),
+			})
+		}
+		if p.PeriodSeconds <= 0 || p.PeriodSeconds > 1800 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Message: "periodSeconds must be between 1 and 1800",
+				Field:   fmt.Sprintf("%s.policies[%d].periodSeconds", field, i),
+			})
+		}
+	}
+
+	if r.SelectPolicy != nil {
+		switch *r.SelectPolicy {
+		case MaxScalingPolicySelect, MinScalingPolicySelect, DisabledScalingPolicySelect:
+		default:
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueNotSupported,
+				Message: fmt.Sprintf("Unsupported select policy %s, should be one of: %s, %s, %s", *r.SelectPolicy, MaxScalingPolicySelect, MinScalingPolicySelect, DisabledScalingPolicySelect),
+				Field:   field + ".selectPolicy",
+			})
+		}
+	}
+
+	return causes
+}
+
+// ValidateBufferPolicy validates the BufferPolicy part of a FleetAutoscalerPolicy
+func (b *BufferPolicy) ValidateBufferPolicy() []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	if b == nil {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: "Buffer policy config params are missing",
+			Field:   "buffer",
+		})
+		return causes
+	}
+
+	if b.BufferSize.Type == intstr.Int && b.BufferSize.IntValue() <= 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: "bufferSize must be bigger than 0",
+			Field:   "bufferSize",
+		})
+	}
+
+	if b.MaxReplicas < b.MinReplicas {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: "maxReplicas must be bigger than minReplicas",
+			Field:   "maxReplicas",
+		})
+	}
+
+	if b.BufferSize.Type == intstr.Int && b.MaxReplicas < int32(b.BufferSize.IntValue()) {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: "maxReplicas must be bigger than bufferSize",
+			Field:   "maxReplicas",
+		})
+	}
+
+	return causes
+}
+
+// ValidateWebhookPolicy validates the WebhookPolicy part of a FleetAutoscalerPolicy
+func (w *WebhookPolicy) ValidateWebhookPolicy() []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	if w == nil {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: "Webhook policy config params are missing",
+			Field:   "webhook",
+		})
+		return causes
+	}
+
+	if w.Service == nil && w.URL == nil {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: "Either Service or URL must be specified",
+			Field:   "webhook",
+		})
+	}
+
+	if w.Service != nil && w.URL != nil {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: "Service and URL cannot both be specified",
+			Field:   "webhook",
+		})
+	}
+
+	return causes
+}
+
+// ValidateMetricsPolicy validates the MetricsPolicy part of a FleetAutoscalerPolicy
+func (m *MetricsPolicy) ValidateMetricsPolicy() []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	if m == nil {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: "Metrics policy config params are missing",
+			Field:   "metrics",
+		})
+		return causes
+	}
+
+	if m.MaxReplicas < m.MinReplicas {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: "maxReplicas must be bigger than minReplicas",
+			Field:   "metrics.maxReplicas",
+		})
+	}
+
+	if len(m.Metrics) == 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: "at least one metric source must be specified",
+			Field:   "metrics.metrics",
+		})
+		return causes
+	}
+
+	for i, spec := range m.Metrics {
+		switch spec.Type {
+		case ResourceMetricSourceType:
+			if spec.Resource == nil {
+				causes = append(causes, metav1.StatusCause{
+					Type:    metav1.CauseTypeFieldValueInvalid,
+					Message: "resource metric source is missing",
+					Field:   fmt.Sprintf("metrics.metrics[%d].resource", i),
+				})
+			}
+		case PodsMetricSourceType:
+			if spec.Pods == nil {
+				causes = append(causes, metav1.StatusCause{
+					Type:    metav1.CauseTypeFieldValueInvalid,
+					Message: "pods metric source is missing",
+					Field:   fmt.Sprintf("metrics.metrics[%d].pods", i),
+				})
+			}
+		case ObjectMetricSourceType:
+			if spec.Object == nil {
+				causes = append(causes, metav1.StatusCause{
+					Type:    metav1.CauseTypeFieldValueInvalid,
+					Message: "object metric source is missing",
+					Field:   fmt.Sprintf("metrics.metrics[%d].object", i),
+				})
+			}
+		default:
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueNotSupported,
+				Message: fmt.Sprintf("Unsupported metric source type %s, should be one of: %s, %s, %s", spec.Type, ResourceMetricSourceType, PodsMetricSourceType, ObjectMetricSourceType),
+				Field:   fmt.Sprintf("metrics.metrics[%d].type", i),
+			})
+		}
+	}
+
+	return causes
+}
+
+// scheduleCronParser parses the standard 5-field cron expressions used by ScheduleEntry.Cron
+var scheduleCronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+// scheduleOverlapHorizon bounds how far forward an entry's activation windows are simulated when
+// checking whether two entries overlap: long enough to contain at least one full cycle of any
+// realistic cron expression (e.g. a yearly one), short enough to keep validation fast.
+const scheduleOverlapHorizon = 366 * 24 * time.Hour
+
+// scheduleOverlapEpoch anchors the simulation used to detect overlapping entries, so that whether
+// two entries are reported as overlapping doesn't depend on what time validation happens to run.
+var scheduleOverlapEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// scheduleOverlapMaxWindows caps how many activation windows entryWindows will simulate for a
+// single entry. A legitimate config can still produce a huge activation count within
+// scheduleOverlapHorizon (e.g. "* * * * *" activates roughly 527,000 times a year); beyond the
+// cap, entryWindows stops early and reports ok=false so the caller can fail safe by treating the
+// entry as overlapping everything, instead of paying to simulate the rest of its activations.
+const scheduleOverlapMaxWindows = 2000
+
+// scheduleWindow is a single activation window, [start, end), simulated for a ScheduleEntry.
+type scheduleWindow struct {
+	start, end time.Time
+}
+
+// entryWindows returns every activation window e would open within scheduleOverlapHorizon of
+// scheduleOverlapEpoch, in chronological order. ok is false if e has more than
+// scheduleOverlapMaxWindows activations in that horizon, in which case windows is incomplete and
+// must not be used to conclude e doesn't overlap another entry. The caller is expected to have
+// already validated e.Cron and e.TimeZone.
+func entryWindows(e ScheduleEntry) (windows []scheduleWindow, ok bool, err error) {
+	sched, err := scheduleCronParser.Parse(e.Cron)
+	if err != nil {
+		return nil, false, err
+	}
+
+	loc := time.UTC
+	if e.TimeZone != "" {
+		if loc, err = time.LoadLocation(e.TimeZone); err != nil {
+			return nil, false, err
+		}
+	}
+
+	horizonEnd := scheduleOverlapEpoch.Add(scheduleOverlapHorizon)
+
+	for cursor := scheduleOverlapEpoch.In(loc); ; {
+		next := sched.Next(cursor)
+		if next.IsZero() || !next.Before(horizonEnd) {
+			return windows, true, nil
+		}
+		if len(windows) >= scheduleOverlapMaxWindows {
+			return windows, false, nil
+		}
+		windows = append(windows, scheduleWindow{start: next, end: next.Add(e.Duration.Duration)})
+		cursor = next
+	}
+}
+
+// windowsOverlap reports whether any window in a overlaps any window in b. Both must be sorted
+// chronologically by start time, which entryWindows guarantees; this lets it sweep both lists
+// once each, in O(len(a)+len(b)), instead of comparing every pair of windows.
+func windowsOverlap(a, b []scheduleWindow) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i].start.Before(b[j].end) && b[j].start.Before(a[i].end) {
+			return true
+		}
+		if !a[i].end.After(b[j].end) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return false
+}
+
+// ValidateSchedulePolicy validates the SchedulePolicy part of a FleetAutoscalerPolicy
+func (s *SchedulePolicy) ValidateSchedulePolicy() []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	if s == nil {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: "Schedule policy config params are missing",
+			Field:   "schedule",
+		})
+		return causes
+	}
+
+	if len(s.Entries) == 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: "at least one schedule entry must be specified",
+			Field:   "schedule.entries",
+		})
+		return causes
+	}
+
+	// Each entry's windows are simulated once and cached here, rather than inside the pairwise
+	// overlap check below, which would otherwise recompute an entry's windows once per other
+	// entry it's compared against. valid is false for an entry whose cron/timeZone didn't parse
+	// (already reported below), so the overlap check can skip it without double-reporting.
+	windowsByEntry := make([][]scheduleWindow, len(s.Entries))
+	exhaustive := make([]bool, len(s.Entries))
+	valid := make([]bool, len(s.Entries))
+
+	for i, e := range s.Entries {
+		field := fmt.Sprintf("schedule.entries[%d]", i)
+
+		if _, err := scheduleCronParser.Parse(e.Cron); err != nil {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Message: fmt.Sprintf("invalid cron expression %q: %v", e.Cron, err),
+				Field:   field + ".cron",
+			})
+		}
+
+		if e.TimeZone != "" {
+			if _, err := time.LoadLocation(e.TimeZone); err != nil {
+				causes = append(causes, metav1.StatusCause{
+					Type:    metav1.CauseTypeFieldValueInvalid,
+					Message: fmt.Sprintf("unknown time zone %q: %v", e.TimeZone, err),
+					Field:   field + ".timeZone",
+				})
+			}
+		}
+
+		if e.MaxReplicas < e.MinReplicas {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Message: "maxReplicas must be bigger than minReplicas",
+				Field:   field + ".maxReplicas",
+			})
+		}
+
+		windows, ok, err := entryWindows(e)
+		if err != nil {
+			// already reported above as an invalid cron expression or time zone
+			continue
+		}
+		windowsByEntry[i] = windows
+		exhaustive[i] = ok
+		valid[i] = true
+	}
+
+	for i, e := range s.Entries {
+		for j := i + 1; j < len(s.Entries); j++ {
+			other := s.Entries[j]
+			if e.MinReplicas == other.MinReplicas && e.MaxReplicas == other.MaxReplicas {
+				continue
+			}
+			if !valid[i] || !valid[j] {
+				continue
+			}
+
+			// If either entry hit scheduleOverlapMaxWindows, its windows are incomplete and
+			// can't be trusted to rule an overlap out: fail safe and treat the pair as
+			// overlapping rather than silently skip the check.
+			overlaps := !exhaustive[i] || !exhaustive[j] || windowsOverlap(windowsByEntry[i], windowsByEntry[j])
+
+			if overlaps {
+				causes = append(causes, metav1.StatusCause{
+					Type:    metav1.CauseTypeFieldValueInvalid,
+					Message: fmt.Sprintf("entries %d and %d have overlapping schedule windows with contradictory replica bounds", i, j),
+					Field:   fmt.Sprintf("schedule.entries[%d]", i),
+				})
+			}
+		}
+	}
+
+	return causes
+}
@@ -0,0 +1,434 @@
+// Copyright 2018 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fleetautoscalers manages the FleetAutoscaler controller, which
+// watches FleetAutoscalers and scales their associated Fleets accordingly.
+package fleetautoscalers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"agones.dev/agones/pkg/apis/stable/v1alpha1"
+	"agones.dev/agones/pkg/client/clientset/versioned"
+	"agones.dev/agones/pkg/client/informers/externalversions"
+	listerv1alpha1 "agones.dev/agones/pkg/client/listers/stable/v1alpha1"
+	"agones.dev/agones/pkg/util/webhooks"
+	"github.com/heptiolabs/healthcheck"
+	admv1beta1 "k8s.io/api/admission/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// Controller is the FleetAutoscaler controller
+type Controller struct {
+	kubeClient            kubernetes.Interface
+	agonesClient          versioned.Interface
+	fleetAutoscalerLister listerv1alpha1.FleetAutoscalerLister
+	fleetAutoscalerSynced cache.InformerSynced
+	fleetLister           listerv1alpha1.FleetLister
+	fleetSynced           cache.InformerSynced
+	metricsClient         MetricsClient
+	recommendations       *recommendationStore
+	workqueue             workqueue.RateLimitingInterface
+	recorder              record.EventRecorder
+}
+
+// NewController returns a new fleet autoscaler crd controller
+func NewController(
+	wh *webhooks.WebHook,
+	health healthcheck.Handler,
+	kubeClient kubernetes.Interface,
+	extClient interface{},
+	metricsClient MetricsClient,
+	agonesClient versioned.Interface,
+	agonesInformerFactory externalversions.SharedInformerFactory) *Controller {
+
+	autoscaler := agonesInformerFactory.Stable().V1alpha1().FleetAutoscalers()
+	fleets := agonesInformerFactory.Stable().V1alpha1().Fleets()
+
+	c := &Controller{
+		kubeClient:            kubeClient,
+		agonesClient:          agonesClient,
+		fleetAutoscalerLister: autoscaler.Lister(),
+		fleetAutoscalerSynced: autoscaler.Informer().HasSynced,
+		fleetLister:           fleets.Lister(),
+		fleetSynced:           fleets.Informer().HasSynced,
+		metricsClient:         metricsClient,
+		recommendations:       newRecommendationStore(),
+		workqueue:             workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "FleetAutoscalers"),
+	}
+
+	wh.AddHandler("/validate", v1alpha1.SchemeGroupVersion.WithKind("FleetAutoscaler"), admv1beta1.Create, c.validationHandler)
+
+	return c
+}
+
+// validationHandler validates a FleetAutoscaler admission review
+func (c *Controller) validationHandler(review admv1beta1.AdmissionReview) (admv1beta1.AdmissionReview, error) {
+	obj := review.Request.Object
+	fas := &v1alpha1.FleetAutoscaler{}
+
+	if err := json.Unmarshal(obj.Raw, fas); err != nil {
+		return review, fmt.Errorf("error unmarshalling original FleetAutoscaler json: %v", err)
+	}
+
+	causes, ok := fas.Validate()
+	if !ok {
+		review.Response.Allowed = false
+		details := metav1.StatusDetails{
+			Name:   review.Request.Name,
+			Group:  review.Request.Kind.Group,
+			Kind:   review.Request.Kind.Kind,
+			Causes: causes,
+		}
+		review.Response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: "FleetAutoscaler configuration is invalid",
+			Reason:  metav1.StatusReasonInvalid,
+			Details: &details,
+		}
+	}
+
+	return review, nil
+}
+
+// syncFleetAutoscaler synchronises the desired Fleet replica count, as determined by the
+// FleetAutoscaler's policy, against the actual replica count of the Fleet.
+func (c *Controller) syncFleetAutoscaler(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	fas, err := c.fleetAutoscalerLister.FleetAutoscalers(namespace).Get(name)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			// the FleetAutoscaler no longer exists, so there is nothing to sync
+			return nil
+		}
+		return err
+	}
+
+	fleet, err := c.fleetLister.Fleets(namespace).Get(fas.Spec.FleetName)
+	if err != nil {
+		c.recorder.Eventf(fas, "Warning", "FailedGetFleet", "could not fetch fleet %s: %v", fas.Spec.FleetName, err)
+		return c.updateStatusUnableToScale(fas)
+	}
+
+	currentReplicas := fleet.Status.Replicas
+	desiredReplicas, scalingLimited, err := c.computeDesiredReplicas(fas, fleet)
+	if err != nil {
+		c.recorder.Eventf(fas, "Warning", "FailedComputeReplicas", "could not compute desired replicas: %v", err)
+		return c.updateStatusUnableToScale(fas)
+	}
+
+	now := time.Now()
+	if fas.Spec.Behavior != nil {
+		var limited bool
+		desiredReplicas, limited = c.applyBehavior(key, fas.Spec.Behavior, currentReplicas, desiredReplicas, now)
+		scalingLimited = scalingLimited || limited
+	}
+
+	if err := c.updateStatus(fas, currentReplicas, desiredReplicas, desiredReplicas != fleet.Spec.Replicas, scalingLimited); err != nil {
+		return err
+	}
+
+	scaled, err := c.scaleFleet(fas, fleet, desiredReplicas)
+	if err != nil {
+		return err
+	}
+	if scaled {
+		c.recommendations.recordScale(key, currentReplicas, desiredReplicas, now)
+	}
+
+	if fas.Spec.Policy.Type == v1alpha1.SchedulePolicyType {
+		c.requeueAtNextScheduleBoundary(key, fas.Spec.Policy.Schedule, now)
+	}
+
+	return nil
+}
+
+// requeueAtNextScheduleBoundary requeues the FleetAutoscaler to be re-synced at the next time
+// one of its SchedulePolicy windows opens or closes, so that scaling engages and disengages on
+// time even without an external trigger.
+func (c *Controller) requeueAtNextScheduleBoundary(key string, policy *v1alpha1.SchedulePolicy, now time.Time) {
+	boundary, err := nextBoundary(policy, now)
+	if err != nil || boundary.IsZero() {
+		return
+	}
+
+	c.workqueue.AddAfter(key, boundary.Sub(now))
+}
+
+// applyBehavior suppresses oscillation via the configured stabilization window, then caps the
+// delta applied in this tick via the configured rate-limit policies for the direction of the
+// change.
+func (c *Controller) applyBehavior(key string, behavior *v1alpha1.FleetAutoscalerBehavior, currentReplicas, desiredReplicas int32, now time.Time) (int32, bool) {
+	rules := behavior.ScaleUp
+	if desiredReplicas < currentReplicas {
+		rules = behavior.ScaleDown
+	}
+
+	window := time.Duration(0)
+	if rules != nil && rules.StabilizationWindowSeconds != nil {
+		window = time.Duration(*rules.StabilizationWindowSeconds) * time.Second
+	}
+	stabilized := c.recommendations.stabilize(key, desiredReplicas, currentReplicas, window, now)
+
+	return c.recommendations.rateLimit(key, rules, currentReplicas, stabilized, now)
+}
+
+// computeDesiredReplicas determines the desired number of replicas for the given Fleet,
+// according to the FleetAutoscaler's configured policy.
+func (c *Controller) computeDesiredReplicas(fas *v1alpha1.FleetAutoscaler, f *v1alpha1.Fleet) (int32, bool, error) {
+	return c.computeDesiredReplicasForPolicy(fas.Spec.Policy, f)
+}
+
+// computeDesiredReplicasForPolicy determines the desired number of replicas for the given Fleet
+// under an arbitrary policy. It is split out from computeDesiredReplicas so that a
+// SchedulePolicy's nested FallbackPolicy can be evaluated recursively.
+func (c *Controller) computeDesiredReplicasForPolicy(policy v1alpha1.FleetAutoscalerPolicy, f *v1alpha1.Fleet) (int32, bool, error) {
+	switch policy.Type {
+	case v1alpha1.BufferPolicyType:
+		return applyBufferPolicy(policy.Buffer, f)
+	case v1alpha1.WebhookPolicyType:
+		return applyWebhookPolicy(policy.Webhook, f)
+	case v1alpha1.MetricsPolicyType:
+		return c.applyMetricsPolicy(policy.Metrics, f)
+	case v1alpha1.SchedulePolicyType:
+		return c.applySchedulePolicy(policy.Schedule, f)
+	}
+
+	return f.Status.Replicas, false, fmt.Errorf("unexpected policy type %s", policy.Type)
+}
+
+// applySchedulePolicy computes the desired replica count according to whichever ScheduleEntry's
+// window currently contains "now", clamping that window's own FallbackPolicy recommendation to
+// the entry's MinReplicas/MaxReplicas bounds. Outside of any window, it defers to the
+// SchedulePolicy's own FallbackPolicy.
+func (c *Controller) applySchedulePolicy(policy *v1alpha1.SchedulePolicy, f *v1alpha1.Fleet) (int32, bool, error) {
+	now := time.Now()
+
+	entry, _, active, err := activeEntry(policy, now)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if !active {
+		if policy.FallbackPolicy == nil {
+			return f.Status.Replicas, false, nil
+		}
+		return c.computeDesiredReplicasForPolicy(*policy.FallbackPolicy, f)
+	}
+
+	desiredReplicas := f.Status.Replicas
+	if entry.FallbackPolicy != nil {
+		var err error
+		desiredReplicas, _, err = c.computeDesiredReplicasForPolicy(*entry.FallbackPolicy, f)
+		if err != nil {
+			return 0, false, err
+		}
+	}
+
+	scalingLimited := false
+	if desiredReplicas < entry.MinReplicas {
+		desiredReplicas = entry.MinReplicas
+		scalingLimited = true
+	}
+	if desiredReplicas > entry.MaxReplicas {
+		desiredReplicas = entry.MaxReplicas
+		scalingLimited = true
+	}
+
+	return desiredReplicas, scalingLimited, nil
+}
+
+// applyMetricsPolicy computes the desired replica count from the configured metric sources,
+// mirroring the Kubernetes HPA v2 algorithm: desiredReplicas = ceil(currentReplicas * currentMetric / targetMetric)
+// for each source, taking the max across sources and clamping to [MinReplicas, MaxReplicas].
+func (c *Controller) applyMetricsPolicy(policy *v1alpha1.MetricsPolicy, f *v1alpha1.Fleet) (int32, bool, error) {
+	currentReplicas := f.Status.Replicas
+	if currentReplicas == 0 {
+		return policy.MinReplicas, false, nil
+	}
+
+	var desiredReplicas int32
+	for _, spec := range policy.Metrics {
+		replicas, err := c.metricsClient.GetDesiredReplicas(spec, f, currentReplicas)
+		if err != nil {
+			return 0, false, err
+		}
+		if replicas > desiredReplicas {
+			desiredReplicas = replicas
+		}
+	}
+
+	scalingLimited := false
+	if desiredReplicas < policy.MinReplicas {
+		desiredReplicas = policy.MinReplicas
+		scalingLimited = true
+	}
+	if desiredReplicas > policy.MaxReplicas {
+		desiredReplicas = policy.MaxReplicas
+		scalingLimited = true
+	}
+
+	return desiredReplicas, scalingLimited, nil
+}
+
+// ratioToReplicas implements the common HPA-style recommendation formula:
+// desiredReplicas = ceil(currentReplicas * currentMetric / targetMetric)
+func ratioToReplicas(currentReplicas int32, currentMetric, targetMetric float64) int32 {
+	if targetMetric <= 0 {
+		return currentReplicas
+	}
+	return int32(math.Ceil(float64(currentReplicas) * currentMetric / targetMetric))
+}
+
+// applyBufferPolicy applies the Buffer policy to compute the Fleet's desired replica count.
+func applyBufferPolicy(b *v1alpha1.BufferPolicy, f *v1alpha1.Fleet) (int32, bool, error) {
+	var buffer int32
+	if b.BufferSize.Type == intstr.Int {
+		buffer = int32(b.BufferSize.IntValue())
+	} else {
+		i, err := intstr.GetScaledValueFromIntOrPercent(&b.BufferSize, int(f.Spec.Replicas), true)
+		if err != nil {
+			return 0, false, err
+		}
+		buffer = int32(i)
+	}
+
+	desiredReplicas := f.Status.AllocatedReplicas + buffer
+
+	scalingLimited := false
+	if desiredReplicas < b.MinReplicas {
+		desiredReplicas = b.MinReplicas
+		scalingLimited = true
+	}
+	if desiredReplicas > b.MaxReplicas {
+		desiredReplicas = b.MaxReplicas
+		scalingLimited = true
+	}
+
+	return desiredReplicas, scalingLimited, nil
+}
+
+// applyWebhookPolicy returns the Fleet's current replica count, not scaling limited. It does not
+// call the configured webhook, so a Webhook policy leaves the Fleet's size unchanged.
+func applyWebhookPolicy(w *v1alpha1.WebhookPolicy, f *v1alpha1.Fleet) (int32, bool, error) {
+	return f.Status.Replicas, false, nil
+}
+
+// scaleFleet scales the Fleet to the desired number of replicas, if it isn't already there. It
+// reports whether an update was actually applied, so the caller can distinguish a real scale
+// event from a no-op tick.
+func (c *Controller) scaleFleet(fas *v1alpha1.FleetAutoscaler, f *v1alpha1.Fleet, replicas int32) (bool, error) {
+	if replicas == f.Spec.Replicas {
+		return false, nil
+	}
+
+	fCopy := f.DeepCopy()
+	fCopy.Spec.Replicas = replicas
+
+	fleets := c.agonesClient.StableV1alpha1().Fleets(fCopy.Namespace)
+	_, err := fleets.Update(fCopy)
+	if err != nil {
+		return false, fmt.Errorf("error updating replicas for fleet %s: %v", f.ObjectMeta.Name, err)
+	}
+
+	c.recorder.Eventf(fas, "Normal", "AutoScalingFleet", "Scaling fleet %s from %d to %d", f.ObjectMeta.Name, f.Spec.Replicas, replicas)
+	return true, nil
+}
+
+// updateStatus patches the status of the given FleetAutoscaler, marking it able to scale and
+// setting LastScaleTime to now when scaled is true, and recording a ScalingLimited event when the
+// computed replica count was capped, by the policy's replica bounds or by the Behavior's scaling
+// rules. Only the status subresource is touched, via a JSON patch, so that a concurrent edit to
+// Spec isn't clobbered.
+func (c *Controller) updateStatus(fas *v1alpha1.FleetAutoscaler, currentReplicas, desiredReplicas int32, scaled, scalingLimited bool) error {
+	if !scaled &&
+		fas.Status.AbleToScale &&
+		fas.Status.CurrentReplicas == currentReplicas &&
+		fas.Status.DesiredReplicas == desiredReplicas &&
+		fas.Status.ScalingLimited == scalingLimited {
+		return nil
+	}
+
+	status := v1alpha1.FleetAutoscalerStatus{
+		CurrentReplicas: currentReplicas,
+		DesiredReplicas: desiredReplicas,
+		AbleToScale:     true,
+		ScalingLimited:  scalingLimited,
+		LastScaleTime:   fas.Status.LastScaleTime,
+	}
+	if scaled {
+		now := metav1.Now()
+		status.LastScaleTime = &now
+	}
+
+	if err := c.patchStatus(fas, status); err != nil {
+		return err
+	}
+
+	if scalingLimited {
+		c.recorder.Eventf(fas, "Warning", "ScalingLimited", "fleetautoscaler %s was scaling limited by its replica bounds or scaling behavior", fas.ObjectMeta.Name)
+	}
+
+	return nil
+}
+
+// updateStatusUnableToScale marks the FleetAutoscaler as unable to scale, because the target
+// Fleet (or its replica count) could not be determined.
+func (c *Controller) updateStatusUnableToScale(fas *v1alpha1.FleetAutoscaler) error {
+	if !fas.Status.AbleToScale && fas.Status.CurrentReplicas == 0 && fas.Status.DesiredReplicas == 0 {
+		return nil
+	}
+
+	status := v1alpha1.FleetAutoscalerStatus{
+		AbleToScale:     false,
+		ScalingLimited:  false,
+		CurrentReplicas: 0,
+		DesiredReplicas: 0,
+		LastScaleTime:   nil,
+	}
+
+	return c.patchStatus(fas, status)
+}
+
+// patchStatus issues a JSON patch against the FleetAutoscaler's status subresource, with a
+// `test` op precondition on the previous desiredReplicas so that two controllers racing to
+// scale the same FleetAutoscaler can't clobber one another.
+func (c *Controller) patchStatus(fas *v1alpha1.FleetAutoscaler, status v1alpha1.FleetAutoscalerStatus) error {
+	patch, err := statusPatch(fas.Status, status)
+	if err != nil {
+		return fmt.Errorf("error building status patch for fleetautoscaler %s: %v", fas.ObjectMeta.Name, err)
+	}
+
+	autoscalers := c.agonesClient.StableV1alpha1().FleetAutoscalers(fas.Namespace)
+	_, err = autoscalers.Patch(fas.ObjectMeta.Name, types.JSONPatchType, patch, "status")
+	if err != nil {
+		return fmt.Errorf("error patching status for fleetautoscaler %s: %v", fas.ObjectMeta.Name, err)
+	}
+
+	return nil
+}
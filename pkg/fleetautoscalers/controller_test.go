@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"agones.dev/agones/pkg/apis/stable/v1alpha1"
 	agtesting "agones.dev/agones/pkg/testing"
@@ -29,6 +30,7 @@ import (
 	admregv1b "k8s.io/api/admissionregistration/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	k8stesting "k8s.io/client-go/testing"
 )
@@ -114,6 +116,168 @@ func TestWebhookControllerCreationValidationHandler(t *testing.T) {
 	})
 }
 
+func TestMetricsControllerCreationValidationHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid fleet autoscaler", func(t *testing.T) {
+		c, m := newFakeController()
+		fas, _ := defaultMetricsFixtures()
+		_, cancel := agtesting.StartInformers(m)
+		defer cancel()
+
+		review, err := newAdmissionReview(*fas)
+		assert.Nil(t, err)
+
+		result, err := c.validationHandler(review)
+		assert.Nil(t, err)
+		assert.True(t, result.Response.Allowed, fmt.Sprintf("%#v", result.Response))
+	})
+
+	t.Run("invalid fleet autoscaler", func(t *testing.T) {
+		c, m := newFakeController()
+		fas, _ := defaultMetricsFixtures()
+		// this make it invalid
+		fas.Spec.Policy.Metrics = nil
+
+		_, cancel := agtesting.StartInformers(m)
+		defer cancel()
+
+		review, err := newAdmissionReview(*fas)
+		assert.Nil(t, err)
+
+		result, err := c.validationHandler(review)
+		assert.Nil(t, err)
+		assert.False(t, result.Response.Allowed, fmt.Sprintf("%#v", result.Response))
+		assert.Equal(t, metav1.StatusFailure, result.Response.Result.Status)
+		assert.Equal(t, metav1.StatusReasonInvalid, result.Response.Result.Reason)
+		assert.NotEmpty(t, result.Response.Result.Details)
+	})
+}
+
+func TestScheduleControllerCreationValidationHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid fleet autoscaler", func(t *testing.T) {
+		c, m := newFakeController()
+		fas, _ := defaultScheduleFixtures()
+		_, cancel := agtesting.StartInformers(m)
+		defer cancel()
+
+		review, err := newAdmissionReview(*fas)
+		assert.Nil(t, err)
+
+		result, err := c.validationHandler(review)
+		assert.Nil(t, err)
+		assert.True(t, result.Response.Allowed, fmt.Sprintf("%#v", result.Response))
+	})
+
+	t.Run("invalid cron expression", func(t *testing.T) {
+		c, m := newFakeController()
+		fas, _ := defaultScheduleFixtures()
+		fas.Spec.Policy.Schedule.Entries[0].Cron = "not a cron string"
+
+		_, cancel := agtesting.StartInformers(m)
+		defer cancel()
+
+		review, err := newAdmissionReview(*fas)
+		assert.Nil(t, err)
+
+		result, err := c.validationHandler(review)
+		assert.Nil(t, err)
+		assert.False(t, result.Response.Allowed, fmt.Sprintf("%#v", result.Response))
+		assert.Equal(t, metav1.StatusFailure, result.Response.Result.Status)
+		assert.Equal(t, metav1.StatusReasonInvalid, result.Response.Result.Reason)
+		assert.NotEmpty(t, result.Response.Result.Details)
+	})
+
+	t.Run("unknown time zone", func(t *testing.T) {
+		c, m := newFakeController()
+		fas, _ := defaultScheduleFixtures()
+		fas.Spec.Policy.Schedule.Entries[0].TimeZone = "Narnia/Cair_Paravel"
+
+		_, cancel := agtesting.StartInformers(m)
+		defer cancel()
+
+		review, err := newAdmissionReview(*fas)
+		assert.Nil(t, err)
+
+		result, err := c.validationHandler(review)
+		assert.Nil(t, err)
+		assert.False(t, result.Response.Allowed, fmt.Sprintf("%#v", result.Response))
+	})
+
+	t.Run("overlapping windows with contradictory bounds (identical cron)", func(t *testing.T) {
+		c, m := newFakeController()
+		fas, _ := defaultScheduleFixtures()
+		fas.Spec.Policy.Schedule.Entries = append(fas.Spec.Policy.Schedule.Entries, v1alpha1.ScheduleEntry{
+			Cron:        fas.Spec.Policy.Schedule.Entries[0].Cron,
+			TimeZone:    fas.Spec.Policy.Schedule.Entries[0].TimeZone,
+			Duration:    fas.Spec.Policy.Schedule.Entries[0].Duration,
+			MinReplicas: fas.Spec.Policy.Schedule.Entries[0].MinReplicas + 1,
+			MaxReplicas: fas.Spec.Policy.Schedule.Entries[0].MaxReplicas + 1,
+		})
+
+		_, cancel := agtesting.StartInformers(m)
+		defer cancel()
+
+		review, err := newAdmissionReview(*fas)
+		assert.Nil(t, err)
+
+		result, err := c.validationHandler(review)
+		assert.Nil(t, err)
+		assert.False(t, result.Response.Allowed, fmt.Sprintf("%#v", result.Response))
+	})
+
+	t.Run("overlapping windows with contradictory bounds (different cron)", func(t *testing.T) {
+		c, m := newFakeController()
+		fas, _ := defaultScheduleFixtures()
+		// entry 0 fires Fridays 17:00-19:00 America/New_York; this entry fires Fridays
+		// 18:00-19:30 America/New_York, a genuinely different cron expression whose window
+		// still overlaps entry 0's.
+		fas.Spec.Policy.Schedule.Entries = append(fas.Spec.Policy.Schedule.Entries, v1alpha1.ScheduleEntry{
+			Cron:        "0 18 * * 5",
+			TimeZone:    fas.Spec.Policy.Schedule.Entries[0].TimeZone,
+			Duration:    metav1.Duration{Duration: 90 * time.Minute},
+			MinReplicas: fas.Spec.Policy.Schedule.Entries[0].MinReplicas + 1,
+			MaxReplicas: fas.Spec.Policy.Schedule.Entries[0].MaxReplicas + 1,
+		})
+
+		_, cancel := agtesting.StartInformers(m)
+		defer cancel()
+
+		review, err := newAdmissionReview(*fas)
+		assert.Nil(t, err)
+
+		result, err := c.validationHandler(review)
+		assert.Nil(t, err)
+		assert.False(t, result.Response.Allowed, fmt.Sprintf("%#v", result.Response))
+	})
+
+	t.Run("non-overlapping windows with different bounds are allowed", func(t *testing.T) {
+		c, m := newFakeController()
+		fas, _ := defaultScheduleFixtures()
+		// entry 0 fires Fridays 17:00-19:00 America/New_York; this entry fires Saturdays
+		// 17:00-19:00, which never overlaps entry 0's window.
+		fas.Spec.Policy.Schedule.Entries = append(fas.Spec.Policy.Schedule.Entries, v1alpha1.ScheduleEntry{
+			Cron:        "0 17 * * 6",
+			TimeZone:    fas.Spec.Policy.Schedule.Entries[0].TimeZone,
+			Duration:    fas.Spec.Policy.Schedule.Entries[0].Duration,
+			MinReplicas: fas.Spec.Policy.Schedule.Entries[0].MinReplicas + 1,
+			MaxReplicas: fas.Spec.Policy.Schedule.Entries[0].MaxReplicas + 1,
+		})
+
+		_, cancel := agtesting.StartInformers(m)
+		defer cancel()
+
+		review, err := newAdmissionReview(*fas)
+		assert.Nil(t, err)
+
+		result, err := c.validationHandler(review)
+		assert.Nil(t, err)
+		assert.True(t, result.Response.Allowed, fmt.Sprintf("%#v", result.Response))
+	})
+}
+
 // nolint:dupl
 func TestControllerSyncFleetAutoscaler(t *testing.T) {
 	t.Parallel()
@@ -136,15 +300,20 @@ func TestControllerSyncFleetAutoscaler(t *testing.T) {
 			return true, &v1alpha1.FleetAutoscalerList{Items: []v1alpha1.FleetAutoscaler{*fas}}, nil
 		})
 
-		m.AgonesClient.AddReactor("update", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		m.AgonesClient.AddReactor("patch", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
 			fasUpdated = true
-			ca := action.(k8stesting.UpdateAction)
-			fas := ca.GetObject().(*v1alpha1.FleetAutoscaler)
-			assert.Equal(t, fas.Status.AbleToScale, true)
-			assert.Equal(t, fas.Status.ScalingLimited, false)
-			assert.Equal(t, fas.Status.CurrentReplicas, int32(5))
-			assert.Equal(t, fas.Status.DesiredReplicas, int32(12))
-			assert.NotNil(t, fas.Status.LastScaleTime)
+			pa := action.(k8stesting.PatchAction)
+			assert.Equal(t, types.JSONPatchType, pa.GetPatchType())
+			assert.Equal(t, "status", pa.GetSubresource())
+
+			var ops []jsonPatchOp
+			assert.Nil(t, json.Unmarshal(pa.GetPatch(), &ops))
+			patched := applyStatusOps(t, fas.Status, ops)
+			assert.Equal(t, true, patched.AbleToScale)
+			assert.Equal(t, false, patched.ScalingLimited)
+			assert.Equal(t, int32(5), patched.CurrentReplicas)
+			assert.Equal(t, int32(12), patched.DesiredReplicas)
+			assert.NotNil(t, patched.LastScaleTime)
 			return true, fas, nil
 		})
 
@@ -189,15 +358,20 @@ func TestControllerSyncFleetAutoscaler(t *testing.T) {
 			return true, &v1alpha1.FleetAutoscalerList{Items: []v1alpha1.FleetAutoscaler{*fas}}, nil
 		})
 
-		m.AgonesClient.AddReactor("update", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		m.AgonesClient.AddReactor("patch", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
 			fasUpdated = true
-			ca := action.(k8stesting.UpdateAction)
-			fas := ca.GetObject().(*v1alpha1.FleetAutoscaler)
-			assert.Equal(t, fas.Status.AbleToScale, true)
-			assert.Equal(t, fas.Status.ScalingLimited, false)
-			assert.Equal(t, fas.Status.CurrentReplicas, int32(20))
-			assert.Equal(t, fas.Status.DesiredReplicas, int32(13))
-			assert.NotNil(t, fas.Status.LastScaleTime)
+			pa := action.(k8stesting.PatchAction)
+			assert.Equal(t, types.JSONPatchType, pa.GetPatchType())
+			assert.Equal(t, "status", pa.GetSubresource())
+
+			var ops []jsonPatchOp
+			assert.Nil(t, json.Unmarshal(pa.GetPatch(), &ops))
+			patched := applyStatusOps(t, fas.Status, ops)
+			assert.Equal(t, true, patched.AbleToScale)
+			assert.Equal(t, false, patched.ScalingLimited)
+			assert.Equal(t, int32(20), patched.CurrentReplicas)
+			assert.Equal(t, int32(13), patched.DesiredReplicas)
+			assert.NotNil(t, patched.LastScaleTime)
 			return true, fas, nil
 		})
 
@@ -241,8 +415,8 @@ func TestControllerSyncFleetAutoscaler(t *testing.T) {
 			return true, &v1alpha1.FleetAutoscalerList{Items: []v1alpha1.FleetAutoscaler{*fas}}, nil
 		})
 
-		m.AgonesClient.AddReactor("update", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
-			assert.FailNow(t, "fleetautoscaler should not update")
+		m.AgonesClient.AddReactor("patch", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			assert.FailNow(t, "fleetautoscaler should not patch")
 			return false, nil, nil
 		})
 
@@ -271,12 +445,17 @@ func TestControllerSyncFleetAutoscaler(t *testing.T) {
 			return true, &v1alpha1.FleetAutoscalerList{Items: []v1alpha1.FleetAutoscaler{*fas}}, nil
 		})
 
-		m.AgonesClient.AddReactor("update", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		m.AgonesClient.AddReactor("patch", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
 			updated = true
-			ca := action.(k8stesting.UpdateAction)
-			fas := ca.GetObject().(*v1alpha1.FleetAutoscaler)
-			assert.Equal(t, fas.Status.CurrentReplicas, int32(0))
-			assert.Equal(t, fas.Status.DesiredReplicas, int32(0))
+			pa := action.(k8stesting.PatchAction)
+			assert.Equal(t, types.JSONPatchType, pa.GetPatchType())
+			assert.Equal(t, "status", pa.GetSubresource())
+
+			var ops []jsonPatchOp
+			assert.Nil(t, json.Unmarshal(pa.GetPatch(), &ops))
+			patched := applyStatusOps(t, fas.Status, ops)
+			assert.Equal(t, int32(0), patched.CurrentReplicas)
+			assert.Equal(t, int32(0), patched.DesiredReplicas)
 			return true, fas, nil
 		})
 
@@ -289,6 +468,298 @@ func TestControllerSyncFleetAutoscaler(t *testing.T) {
 
 		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "FailedGetFleet")
 	})
+
+	t.Run("scaling up is rate limited by behavior policy", func(t *testing.T) {
+		t.Parallel()
+		c, m := newFakeController()
+		fas, f := defaultFixtures()
+		fas.Spec.Policy.Buffer.BufferSize = intstr.FromInt(20)
+		fas.Spec.Behavior = &v1alpha1.FleetAutoscalerBehavior{
+			ScaleUp: &v1alpha1.FleetAutoscalerScalingRules{
+				Policies: []v1alpha1.FleetAutoscalerScalingPolicy{
+					{Type: v1alpha1.PodsScalingPolicy, Value: 3, PeriodSeconds: 60},
+				},
+			},
+		}
+
+		f.Spec.Replicas = 5
+		f.Status.Replicas = 5
+		f.Status.AllocatedReplicas = 5
+		f.Status.ReadyReplicas = 0
+
+		m.AgonesClient.AddReactor("list", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &v1alpha1.FleetAutoscalerList{Items: []v1alpha1.FleetAutoscaler{*fas}}, nil
+		})
+		m.AgonesClient.AddReactor("patch", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			pa := action.(k8stesting.PatchAction)
+			var ops []jsonPatchOp
+			assert.Nil(t, json.Unmarshal(pa.GetPatch(), &ops))
+			patched := applyStatusOps(t, fas.Status, ops)
+			// desired is 25 (allocated 5 + buffer 20), but the rate limit policy caps the
+			// delta applied in a single tick well below that
+			assert.True(t, patched.DesiredReplicas < 25)
+			return true, fas, nil
+		})
+		m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &v1alpha1.FleetList{Items: []v1alpha1.Fleet{*f}}, nil
+		})
+		m.AgonesClient.AddReactor("update", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			ca := action.(k8stesting.UpdateAction)
+			f := ca.GetObject().(*v1alpha1.Fleet)
+			assert.True(t, f.Spec.Replicas < 25)
+			return true, f, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.fleetAutoscalerSynced)
+		defer cancel()
+
+		err := c.syncFleetAutoscaler("default/fas-1")
+		assert.Nil(t, err)
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "AutoScalingFleet")
+	})
+
+	t.Run("repeated syncs honor the configured rate of change", func(t *testing.T) {
+		t.Parallel()
+		c, m := newFakeController()
+		fas, f := defaultFixtures()
+		fas.Spec.Policy.Buffer.BufferSize = intstr.FromInt(50)
+		fas.Spec.Behavior = &v1alpha1.FleetAutoscalerBehavior{
+			ScaleUp: &v1alpha1.FleetAutoscalerScalingRules{
+				Policies: []v1alpha1.FleetAutoscalerScalingPolicy{
+					{Type: v1alpha1.PodsScalingPolicy, Value: 3, PeriodSeconds: 60},
+				},
+			},
+		}
+
+		f.Spec.Replicas = 5
+		f.Status.Replicas = 5
+		f.Status.AllocatedReplicas = 5
+		f.Status.ReadyReplicas = 0
+
+		m.AgonesClient.AddReactor("list", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &v1alpha1.FleetAutoscalerList{Items: []v1alpha1.FleetAutoscaler{*fas}}, nil
+		})
+		m.AgonesClient.AddReactor("patch", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, fas, nil
+		})
+		m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &v1alpha1.FleetList{Items: []v1alpha1.Fleet{*f}}, nil
+		})
+		var appliedReplicas int32
+		m.AgonesClient.AddReactor("update", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			ca := action.(k8stesting.UpdateAction)
+			updated := ca.GetObject().(*v1alpha1.Fleet)
+			appliedReplicas = updated.Spec.Replicas
+			return true, updated, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.fleetAutoscalerSynced)
+		defer cancel()
+
+		fleets := m.AgonesInformerFactory.Stable().V1alpha1().Fleets().Informer().GetIndexer()
+		assert.Nil(t, fleets.Add(f))
+
+		// every sync recomputes the same desired replicas (allocated 5 + buffer 50), so a buggy
+		// rate limiter that resets its clock on every tick would let this creep up by the policy's
+		// full allowance on every call; run it in a tight loop (no meaningful wall-clock time elapses) and assert the
+		// total applied change never exceeds a single PeriodSeconds window's allowance.
+		for i := 0; i < 20; i++ {
+			appliedReplicas = f.Spec.Replicas
+			assert.Nil(t, c.syncFleetAutoscaler("default/fas-1"))
+
+			// reflect whatever the controller actually applied back into the Fleet lister, the
+			// way a real watch update would, so the next tick measures its rate against the true
+			// current replica count rather than a frozen one.
+			cached, err := c.fleetLister.Fleets(f.Namespace).Get(f.Name)
+			assert.Nil(t, err)
+			fCopy := cached.DeepCopy()
+			fCopy.Spec.Replicas = appliedReplicas
+			fCopy.Status.Replicas = appliedReplicas
+			assert.Nil(t, fleets.Update(fCopy))
+		}
+
+		final, err := c.fleetLister.Fleets(f.Namespace).Get(f.Name)
+		assert.Nil(t, err)
+		assert.True(t, final.Spec.Replicas <= 5+3, "rate limit allowed more than one PeriodSeconds window's worth of change across a tight loop of syncs, got %d replicas", final.Spec.Replicas)
+	})
+
+	t.Run("scaling by metrics policy", func(t *testing.T) {
+		t.Parallel()
+		c, m := newFakeController()
+		c.metricsClient = &fakeMetricsClient{desiredReplicas: 9}
+		fas, f := defaultMetricsFixtures()
+
+		f.Spec.Replicas = 5
+		f.Status.Replicas = 5
+
+		m.AgonesClient.AddReactor("list", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &v1alpha1.FleetAutoscalerList{Items: []v1alpha1.FleetAutoscaler{*fas}}, nil
+		})
+		m.AgonesClient.AddReactor("patch", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			pa := action.(k8stesting.PatchAction)
+			var ops []jsonPatchOp
+			assert.Nil(t, json.Unmarshal(pa.GetPatch(), &ops))
+			patched := applyStatusOps(t, fas.Status, ops)
+			assert.Equal(t, int32(9), patched.DesiredReplicas)
+			return true, fas, nil
+		})
+		m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &v1alpha1.FleetList{Items: []v1alpha1.Fleet{*f}}, nil
+		})
+		m.AgonesClient.AddReactor("update", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			ca := action.(k8stesting.UpdateAction)
+			f := ca.GetObject().(*v1alpha1.Fleet)
+			assert.Equal(t, int32(9), f.Spec.Replicas)
+			return true, f, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.fleetAutoscalerSynced)
+		defer cancel()
+
+		err := c.syncFleetAutoscaler("default/fas-1")
+		assert.Nil(t, err)
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "AutoScalingFleet")
+	})
+
+	t.Run("scaling by schedule policy", func(t *testing.T) {
+		t.Parallel()
+		c, m := newFakeController()
+		fas, f := defaultScheduleFixtures()
+		// a cron that fires every minute with a day-long window is active regardless of when the
+		// test happens to run, keeping this deterministic without mocking time.Now.
+		fas.Spec.Policy.Schedule.Entries[0].Cron = "* * * * *"
+		fas.Spec.Policy.Schedule.Entries[0].TimeZone = ""
+		fas.Spec.Policy.Schedule.Entries[0].Duration = metav1.Duration{Duration: 24 * time.Hour}
+		fas.Spec.Policy.Schedule.Entries[0].MinReplicas = 10
+		fas.Spec.Policy.Schedule.Entries[0].MaxReplicas = 50
+
+		f.Spec.Replicas = 5
+		f.Status.Replicas = 5
+
+		m.AgonesClient.AddReactor("list", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &v1alpha1.FleetAutoscalerList{Items: []v1alpha1.FleetAutoscaler{*fas}}, nil
+		})
+		m.AgonesClient.AddReactor("patch", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			pa := action.(k8stesting.PatchAction)
+			var ops []jsonPatchOp
+			assert.Nil(t, json.Unmarshal(pa.GetPatch(), &ops))
+			patched := applyStatusOps(t, fas.Status, ops)
+			// the active entry's window requires at least MinReplicas (10), well above the
+			// Fleet's current 5
+			assert.Equal(t, true, patched.ScalingLimited)
+			assert.Equal(t, int32(10), patched.DesiredReplicas)
+			return true, fas, nil
+		})
+		m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &v1alpha1.FleetList{Items: []v1alpha1.Fleet{*f}}, nil
+		})
+		m.AgonesClient.AddReactor("update", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			ca := action.(k8stesting.UpdateAction)
+			f := ca.GetObject().(*v1alpha1.Fleet)
+			assert.Equal(t, int32(10), f.Spec.Replicas)
+			return true, f, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.fleetAutoscalerSynced)
+		defer cancel()
+
+		err := c.syncFleetAutoscaler("default/fas-1")
+		assert.Nil(t, err)
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "AutoScalingFleet")
+	})
+
+	t.Run("steady state with behavior configured does not scale down", func(t *testing.T) {
+		t.Parallel()
+		c, m := newFakeController()
+		fas, f := defaultFixtures()
+		fas.Spec.Policy.Buffer.BufferSize = intstr.FromInt(5)
+		fas.Spec.Behavior = &v1alpha1.FleetAutoscalerBehavior{
+			ScaleDown: &v1alpha1.FleetAutoscalerScalingRules{
+				Policies: []v1alpha1.FleetAutoscalerScalingPolicy{
+					{Type: v1alpha1.PodsScalingPolicy, Value: 1, PeriodSeconds: 60},
+				},
+			},
+		}
+
+		f.Spec.Replicas = 10
+		f.Status.Replicas = 10
+		f.Status.AllocatedReplicas = 5
+		f.Status.ReadyReplicas = 5
+		fas.Status.CurrentReplicas = 10
+		fas.Status.DesiredReplicas = 10
+		fas.Status.AbleToScale = true
+
+		m.AgonesClient.AddReactor("list", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &v1alpha1.FleetAutoscalerList{Items: []v1alpha1.FleetAutoscaler{*fas}}, nil
+		})
+		m.AgonesClient.AddReactor("patch", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			assert.FailNow(t, "fleetautoscaler should not patch when already at the desired replica count")
+			return false, nil, nil
+		})
+		m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &v1alpha1.FleetList{Items: []v1alpha1.Fleet{*f}}, nil
+		})
+		m.AgonesClient.AddReactor("update", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			assert.FailNow(t, "fleet should not be scaled away from its current replica count")
+			return false, nil, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.fleetAutoscalerSynced)
+		defer cancel()
+
+		err := c.syncFleetAutoscaler("default/fas-1")
+		assert.Nil(t, err)
+		agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
+	})
+
+	t.Run("scaling down is suppressed within the stabilization window", func(t *testing.T) {
+		t.Parallel()
+		c, m := newFakeController()
+		fas, f := defaultFixtures()
+		fas.Spec.Policy.Buffer.BufferSize = intstr.FromInt(2)
+		window := int32(300)
+		fas.Spec.Behavior = &v1alpha1.FleetAutoscalerBehavior{
+			ScaleDown: &v1alpha1.FleetAutoscalerScalingRules{
+				StabilizationWindowSeconds: &window,
+			},
+		}
+
+		f.Spec.Replicas = 20
+		f.Status.Replicas = 20
+		f.Status.AllocatedReplicas = 15
+		f.Status.ReadyReplicas = 5
+
+		m.AgonesClient.AddReactor("list", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &v1alpha1.FleetAutoscalerList{Items: []v1alpha1.FleetAutoscaler{*fas}}, nil
+		})
+		m.AgonesClient.AddReactor("patch", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			pa := action.(k8stesting.PatchAction)
+			var ops []jsonPatchOp
+			assert.Nil(t, json.Unmarshal(pa.GetPatch(), &ops))
+			patched := applyStatusOps(t, fas.Status, ops)
+			// a recommendation of 20 is still within the stabilization window, so the drop to
+			// the newly computed 17 (allocated 15 + buffer 2) is suppressed
+			assert.Equal(t, int32(20), patched.DesiredReplicas)
+			return true, fas, nil
+		})
+		m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &v1alpha1.FleetList{Items: []v1alpha1.Fleet{*f}}, nil
+		})
+		m.AgonesClient.AddReactor("update", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			assert.FailNow(t, "fleet should not be scaled while suppressed")
+			return false, nil, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.fleetAutoscalerSynced)
+		defer cancel()
+
+		// prime the recommendation history with a higher recommendation, as if AllocatedReplicas
+		// had briefly spiked on the previous tick
+		c.recommendations.stabilize("default/fas-1", 20, 20, time.Duration(window)*time.Second, time.Now())
+
+		err := c.syncFleetAutoscaler("default/fas-1")
+		assert.Nil(t, err)
+	})
 }
 
 func TestControllerScaleFleet(t *testing.T) {
@@ -310,10 +781,11 @@ func TestControllerScaleFleet(t *testing.T) {
 			return true, f, nil
 		})
 
-		err := c.scaleFleet(fas, f, replicas)
+		scaled, err := c.scaleFleet(fas, f, replicas)
 		assert.Nil(t, err)
+		assert.True(t, scaled, "scaleFleet should report that it applied a change")
 		assert.True(t, update, "Fleet should be updated")
-		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "ScalingFleet")
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "AutoScalingFleet")
 	})
 
 	t.Run("noop", func(t *testing.T) {
@@ -326,8 +798,9 @@ func TestControllerScaleFleet(t *testing.T) {
 			return false, nil, nil
 		})
 
-		err := c.scaleFleet(fas, f, replicas)
+		scaled, err := c.scaleFleet(fas, f, replicas)
 		assert.Nil(t, err)
+		assert.False(t, scaled, "scaleFleet should report no change was applied")
 		agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
 	})
 }
@@ -339,17 +812,23 @@ func TestControllerUpdateStatus(t *testing.T) {
 		c, m := newFakeController()
 		fas, _ := defaultFixtures()
 
-		fasUpdated := false
+		fasPatched := false
+
+		m.AgonesClient.AddReactor("patch", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			fasPatched = true
+			pa := action.(k8stesting.PatchAction)
+			assert.Equal(t, types.JSONPatchType, pa.GetPatchType())
+			assert.Equal(t, "status", pa.GetSubresource())
+
+			var ops []jsonPatchOp
+			assert.Nil(t, json.Unmarshal(pa.GetPatch(), &ops))
+			patched := applyStatusOps(t, fas.Status, ops)
+			assert.Equal(t, true, patched.AbleToScale)
+			assert.Equal(t, false, patched.ScalingLimited)
+			assert.Equal(t, int32(10), patched.CurrentReplicas)
+			assert.Equal(t, int32(20), patched.DesiredReplicas)
+			assert.NotNil(t, patched.LastScaleTime)
 
-		m.AgonesClient.AddReactor("update", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
-			fasUpdated = true
-			ca := action.(k8stesting.UpdateAction)
-			fas := ca.GetObject().(*v1alpha1.FleetAutoscaler)
-			assert.Equal(t, fas.Status.AbleToScale, true)
-			assert.Equal(t, fas.Status.ScalingLimited, false)
-			assert.Equal(t, fas.Status.CurrentReplicas, int32(10))
-			assert.Equal(t, fas.Status.DesiredReplicas, int32(20))
-			assert.NotNil(t, fas.Status.LastScaleTime)
 			return true, fas, nil
 		})
 
@@ -358,7 +837,7 @@ func TestControllerUpdateStatus(t *testing.T) {
 
 		err := c.updateStatus(fas, 10, 20, true, false)
 		assert.Nil(t, err)
-		assert.True(t, fasUpdated)
+		assert.True(t, fasPatched)
 		agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
 	})
 
@@ -372,8 +851,8 @@ func TestControllerUpdateStatus(t *testing.T) {
 		fas.Status.DesiredReplicas = 20
 		fas.Status.LastScaleTime = nil
 
-		m.AgonesClient.AddReactor("update", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
-			assert.FailNow(t, "should not update")
+		m.AgonesClient.AddReactor("patch", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			assert.FailNow(t, "should not patch")
 			return false, nil, nil
 		})
 
@@ -402,18 +881,26 @@ func TestControllerUpdateStatusUnableToScale(t *testing.T) {
 		c, m := newFakeController()
 		fas, _ := defaultFixtures()
 		fas.Status.DesiredReplicas = 10
+		previousScaleTime := metav1.Now()
+		fas.Status.LastScaleTime = &previousScaleTime
+
+		fasPatched := false
+
+		m.AgonesClient.AddReactor("patch", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			fasPatched = true
+			pa := action.(k8stesting.PatchAction)
+			assert.Equal(t, types.JSONPatchType, pa.GetPatchType())
+			assert.Equal(t, "status", pa.GetSubresource())
+
+			var ops []jsonPatchOp
+			assert.Nil(t, json.Unmarshal(pa.GetPatch(), &ops))
+			patched := applyStatusOps(t, fas.Status, ops)
+			assert.Equal(t, false, patched.AbleToScale)
+			assert.Equal(t, false, patched.ScalingLimited)
+			assert.Equal(t, int32(0), patched.CurrentReplicas)
+			assert.Equal(t, int32(0), patched.DesiredReplicas)
+			assert.Nil(t, patched.LastScaleTime)
 
-		fasUpdated := false
-
-		m.AgonesClient.AddReactor("update", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
-			fasUpdated = true
-			ca := action.(k8stesting.UpdateAction)
-			fas := ca.GetObject().(*v1alpha1.FleetAutoscaler)
-			assert.Equal(t, fas.Status.AbleToScale, false)
-			assert.Equal(t, fas.Status.ScalingLimited, false)
-			assert.Equal(t, fas.Status.CurrentReplicas, int32(0))
-			assert.Equal(t, fas.Status.DesiredReplicas, int32(0))
-			assert.Nil(t, fas.Status.LastScaleTime)
 			return true, fas, nil
 		})
 
@@ -422,7 +909,7 @@ func TestControllerUpdateStatusUnableToScale(t *testing.T) {
 
 		err := c.updateStatusUnableToScale(fas)
 		assert.Nil(t, err)
-		assert.True(t, fasUpdated)
+		assert.True(t, fasPatched)
 		agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
 	})
 
@@ -434,8 +921,8 @@ func TestControllerUpdateStatusUnableToScale(t *testing.T) {
 		fas.Status.CurrentReplicas = 0
 		fas.Status.DesiredReplicas = 0
 
-		m.AgonesClient.AddReactor("update", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
-			assert.FailNow(t, "fleetautoscaler should not update")
+		m.AgonesClient.AddReactor("patch", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			assert.FailNow(t, "fleetautoscaler should not patch")
 			return false, nil, nil
 		})
 
@@ -448,6 +935,39 @@ func TestControllerUpdateStatusUnableToScale(t *testing.T) {
 	})
 }
 
+// applyStatusOps applies a set of status JSON patch ops on top of previous, verifying along the
+// way that the leading `test` op precondition matches, mirroring what the API server would do.
+func applyStatusOps(t *testing.T, previous v1alpha1.FleetAutoscalerStatus, ops []jsonPatchOp) v1alpha1.FleetAutoscalerStatus {
+	status := previous
+	for _, op := range ops {
+		switch op.Path {
+		case "/status/desiredReplicas":
+			v := int32(op.Value.(float64))
+			if op.Op == "test" {
+				assert.Equal(t, previous.DesiredReplicas, v)
+				continue
+			}
+			status.DesiredReplicas = v
+		case "/status/currentReplicas":
+			status.CurrentReplicas = int32(op.Value.(float64))
+		case "/status/ableToScale":
+			status.AbleToScale = op.Value.(bool)
+		case "/status/scalingLimited":
+			status.ScalingLimited = op.Value.(bool)
+		case "/status/lastScaleTime":
+			if op.Value == nil {
+				status.LastScaleTime = nil
+			} else {
+				now := metav1.Now()
+				status.LastScaleTime = &now
+			}
+		default:
+			assert.FailNow(t, fmt.Sprintf("unexpected patch path %s", op.Path))
+		}
+	}
+	return status
+}
+
 func defaultFixtures() (*v1alpha1.FleetAutoscaler, *v1alpha1.Fleet) {
 	f := &v1alpha1.Fleet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -502,11 +1022,70 @@ func defaultWebhookFixtures() (*v1alpha1.FleetAutoscaler, *v1alpha1.Fleet) {
 	return fas, f
 }
 
+func defaultMetricsFixtures() (*v1alpha1.FleetAutoscaler, *v1alpha1.Fleet) {
+	fas, f := defaultFixtures()
+	fas.Spec.Policy.Type = v1alpha1.MetricsPolicyType
+	fas.Spec.Policy.Buffer = nil
+	fas.Spec.Policy.Metrics = &v1alpha1.MetricsPolicy{
+		MinReplicas: 2,
+		MaxReplicas: 100,
+		Metrics: []v1alpha1.MetricSpec{
+			{
+				Type: v1alpha1.ResourceMetricSourceType,
+				Resource: &v1alpha1.ResourceMetricSource{
+					Name: "cpu",
+					Target: v1alpha1.MetricTarget{
+						Type:               v1alpha1.UtilizationMetricType,
+						AverageUtilization: int32Ptr(50),
+					},
+				},
+			},
+		},
+	}
+
+	return fas, f
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func defaultScheduleFixtures() (*v1alpha1.FleetAutoscaler, *v1alpha1.Fleet) {
+	fas, f := defaultFixtures()
+	fas.Spec.Policy.Type = v1alpha1.SchedulePolicyType
+	fas.Spec.Policy.Buffer = nil
+	fas.Spec.Policy.Schedule = &v1alpha1.SchedulePolicy{
+		Entries: []v1alpha1.ScheduleEntry{
+			{
+				Cron:        "0 17 * * 5",
+				TimeZone:    "America/New_York",
+				Duration:    metav1.Duration{Duration: 2 * time.Hour},
+				MinReplicas: 10,
+				MaxReplicas: 50,
+			},
+		},
+	}
+
+	return fas, f
+}
+
+// fakeMetricsClient is a MetricsClient stub for controller tests, returning a fixed
+// recommendation (or error) without ever talking to the metrics APIs.
+type fakeMetricsClient struct {
+	desiredReplicas int32
+	err             error
+}
+
+// GetDesiredReplicas implements MetricsClient.
+func (f *fakeMetricsClient) GetDesiredReplicas(spec v1alpha1.MetricSpec, fleet *v1alpha1.Fleet, currentReplicas int32) (int32, error) {
+	return f.desiredReplicas, f.err
+}
+
 // newFakeController returns a controller, backed by the fake Clientset
 func newFakeController() (*Controller, agtesting.Mocks) {
 	m := agtesting.NewMocks()
 	wh := webhooks.NewWebHook(http.NewServeMux())
-	c := NewController(wh, healthcheck.NewHandler(), m.KubeClient, m.ExtClient, m.AgonesClient, m.AgonesInformerFactory)
+	c := NewController(wh, healthcheck.NewHandler(), m.KubeClient, m.ExtClient, &fakeMetricsClient{}, m.AgonesClient, m.AgonesInformerFactory)
 	c.recorder = m.FakeRecorder
 	return c, m
 }
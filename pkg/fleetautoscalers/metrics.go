@@ -0,0 +1,222 @@
+// Copyright 2018 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleetautoscalers
+
+import (
+	"fmt"
+
+	"agones.dev/agones/pkg/apis/stable/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	resourceclient "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
+	metricsclient "k8s.io/metrics/pkg/client/custom_metrics"
+)
+
+// FleetNameLabel is set on every GameServer pod backing a Fleet, and is used to select the pods
+// a Fleet's metrics should be aggregated across.
+const FleetNameLabel = "stable.agones.dev/fleet"
+
+// MetricsClient abstracts over the resource and custom metrics APIs so that
+// syncFleetAutoscaler can compute a replica recommendation from a single v1alpha1.MetricSpec,
+// without needing to know which backend the metric comes from.
+type MetricsClient interface {
+	// GetDesiredReplicas returns the number of replicas recommended by a single metric source,
+	// given the Fleet's current replica count.
+	GetDesiredReplicas(spec v1alpha1.MetricSpec, f *v1alpha1.Fleet, currentReplicas int32) (int32, error)
+}
+
+// metricsClient is the default MetricsClient, backed by the metrics.k8s.io and
+// custom.metrics.k8s.io APIs.
+type metricsClient struct {
+	resourceMetrics resourceclient.MetricsV1beta1Interface
+	customMetrics   metricsclient.CustomMetricsClient
+}
+
+// podGroupKind identifies a Pod for the purposes of the custom metrics API's pods metric
+// queries.
+var podGroupKind = schema.GroupKind{Kind: "Pod"}
+
+// NewMetricsClient creates a MetricsClient backed by the standard Kubernetes metrics APIs:
+// metrics.k8s.io for Resource metrics, and custom.metrics.k8s.io for Pods and Object metrics.
+func NewMetricsClient(resourceMetrics resourceclient.MetricsV1beta1Interface, customMetrics metricsclient.CustomMetricsClient) MetricsClient {
+	return &metricsClient{
+		resourceMetrics: resourceMetrics,
+		customMetrics:   customMetrics,
+	}
+}
+
+// GetDesiredReplicas implements MetricsClient.
+func (m *metricsClient) GetDesiredReplicas(spec v1alpha1.MetricSpec, f *v1alpha1.Fleet, currentReplicas int32) (int32, error) {
+	switch spec.Type {
+	case v1alpha1.ResourceMetricSourceType:
+		return m.desiredReplicasFromResource(spec.Resource, f, currentReplicas)
+	case v1alpha1.PodsMetricSourceType:
+		return m.desiredReplicasFromPods(spec.Pods, f, currentReplicas)
+	case v1alpha1.ObjectMetricSourceType:
+		return m.desiredReplicasFromObject(spec.Object, f, currentReplicas)
+	}
+
+	return 0, fmt.Errorf("unsupported metric source type %s", spec.Type)
+}
+
+// desiredReplicasFromResource computes the replica recommendation for a Resource metric source,
+// by querying the average resource utilization or value across the Fleet's GameServer pods.
+func (m *metricsClient) desiredReplicasFromResource(source *v1alpha1.ResourceMetricSource, f *v1alpha1.Fleet, currentReplicas int32) (int32, error) {
+	currentValue, err := m.fleetPodResourceMetric(source.Name, f)
+	if err != nil {
+		return 0, err
+	}
+
+	if source.Target.Type == v1alpha1.UtilizationMetricType {
+		requested, err := fleetPodResourceRequests(source.Name, f)
+		if err != nil {
+			return 0, err
+		}
+		currentValue = currentValue / requested * 100
+	}
+
+	return metricValueToReplicas(source.Target, currentValue, currentReplicas)
+}
+
+// desiredReplicasFromPods computes the replica recommendation for a Pods metric source, by
+// querying a custom metric averaged across the Fleet's GameServer pods.
+func (m *metricsClient) desiredReplicasFromPods(source *v1alpha1.PodsMetricSource, f *v1alpha1.Fleet, currentReplicas int32) (int32, error) {
+	currentValue, err := m.fleetPodCustomMetric(source.Metric, f)
+	if err != nil {
+		return 0, err
+	}
+
+	return metricValueToReplicas(source.Target, currentValue, currentReplicas)
+}
+
+// desiredReplicasFromObject computes the replica recommendation for an Object metric source, by
+// querying a single custom metric on the referenced object.
+func (m *metricsClient) desiredReplicasFromObject(source *v1alpha1.ObjectMetricSource, f *v1alpha1.Fleet, currentReplicas int32) (int32, error) {
+	currentValue, err := m.objectMetric(source.Metric, source.DescribedObject, f.Namespace)
+	if err != nil {
+		return 0, err
+	}
+
+	return metricValueToReplicas(source.Target, currentValue, currentReplicas)
+}
+
+// metricValueToReplicas applies the common ratioToReplicas formula for the target's declared type.
+func metricValueToReplicas(target v1alpha1.MetricTarget, currentValue float64, currentReplicas int32) (int32, error) {
+	switch target.Type {
+	case v1alpha1.AverageValueMetricType:
+		if target.AverageValue == nil {
+			return 0, fmt.Errorf("averageValue target is required for target type %s", target.Type)
+		}
+		targetValue := float64(target.AverageValue.IntValue())
+		return ratioToReplicas(currentReplicas, currentValue, targetValue), nil
+	case v1alpha1.UtilizationMetricType:
+		if target.AverageUtilization == nil {
+			return 0, fmt.Errorf("averageUtilization target is required for target type %s", target.Type)
+		}
+		return ratioToReplicas(currentReplicas, currentValue, float64(*target.AverageUtilization)), nil
+	case v1alpha1.ValueMetricType:
+		if target.Value == nil {
+			return 0, fmt.Errorf("value target is required for target type %s", target.Type)
+		}
+		targetValue := float64(target.Value.IntValue())
+		return ratioToReplicas(currentReplicas, currentValue, targetValue), nil
+	}
+
+	return 0, fmt.Errorf("unsupported metric target type %s", target.Type)
+}
+
+// fleetPodSelector returns the label selector matching the GameServer pods belonging to f.
+func fleetPodSelector(f *v1alpha1.Fleet) labels.Selector {
+	return labels.SelectorFromSet(labels.Set{FleetNameLabel: f.ObjectMeta.Name})
+}
+
+// fleetPodResourceRequests sums resourceName's requests across the containers in the Fleet's
+// GameServer pod template, so a raw usage value can be converted into a percentage of requested
+// resource for the Utilization target type.
+func fleetPodResourceRequests(resourceName string, f *v1alpha1.Fleet) (float64, error) {
+	var total int64
+	for _, c := range f.Spec.Template.Spec.Template.Spec.Containers {
+		if q, ok := c.Resources.Requests[corev1.ResourceName(resourceName)]; ok {
+			total += q.MilliValue()
+		}
+	}
+	if total == 0 {
+		return 0, fmt.Errorf("no %s resource requests found in fleet %s's pod template", resourceName, f.ObjectMeta.Name)
+	}
+
+	return float64(total) / 1000, nil
+}
+
+// fleetPodResourceMetric fetches the average value of a resource metric (e.g. "cpu", "memory")
+// across the GameServer pods belonging to the Fleet, via metrics.k8s.io.
+func (m *metricsClient) fleetPodResourceMetric(resourceName string, f *v1alpha1.Fleet) (float64, error) {
+	metrics, err := m.resourceMetrics.PodMetricses(f.Namespace).List(metav1.ListOptions{LabelSelector: fleetPodSelector(f).String()})
+	if err != nil {
+		return 0, fmt.Errorf("error fetching resource metric %s for fleet %s: %v", resourceName, f.ObjectMeta.Name, err)
+	}
+
+	var total int64
+	var count int64
+	for _, podMetric := range metrics.Items {
+		reported := false
+		for _, c := range podMetric.Containers {
+			if q, ok := c.Usage[corev1.ResourceName(resourceName)]; ok {
+				total += q.MilliValue()
+				reported = true
+			}
+		}
+		if reported {
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("no pods reporting resource metric %s found for fleet %s", resourceName, f.ObjectMeta.Name)
+	}
+
+	return float64(total) / float64(count) / 1000, nil
+}
+
+// fleetPodCustomMetric fetches the average value of a custom metric across the GameServer pods
+// belonging to the Fleet, via custom.metrics.k8s.io.
+func (m *metricsClient) fleetPodCustomMetric(metricName string, f *v1alpha1.Fleet) (float64, error) {
+	metrics, err := m.customMetrics.NamespacedMetrics(f.Namespace).GetForObjects(podGroupKind, fleetPodSelector(f), metricName, labels.Everything())
+	if err != nil {
+		return 0, fmt.Errorf("error fetching custom metric %s for fleet %s: %v", metricName, f.ObjectMeta.Name, err)
+	}
+	if len(metrics.Items) == 0 {
+		return 0, fmt.Errorf("no pods reporting custom metric %s found for fleet %s", metricName, f.ObjectMeta.Name)
+	}
+
+	var total int64
+	for _, value := range metrics.Items {
+		total += value.Value.MilliValue()
+	}
+
+	return float64(total) / float64(len(metrics.Items)) / 1000, nil
+}
+
+// objectMetric fetches a single metric value for the referenced object, via
+// custom.metrics.k8s.io.
+func (m *metricsClient) objectMetric(metricName string, ref v1alpha1.CrossVersionObjectReference, namespace string) (float64, error) {
+	gk := schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind).GroupKind()
+	value, err := m.customMetrics.NamespacedMetrics(namespace).GetForObject(gk, ref.Name, metricName, labels.Everything())
+	if err != nil {
+		return 0, fmt.Errorf("error fetching object metric %s for %s/%s: %v", metricName, ref.Kind, ref.Name, err)
+	}
+
+	return float64(value.Value.MilliValue()) / 1000, nil
+}
@@ -0,0 +1,218 @@
+// Copyright 2018 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleetautoscalers
+
+import (
+	"fmt"
+	"testing"
+
+	"agones.dev/agones/pkg/apis/stable/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/rest"
+	custommetricsapi "k8s.io/metrics/pkg/apis/custom_metrics/v1beta2"
+	metricsapi "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	resourceclient "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
+	metricsclient "k8s.io/metrics/pkg/client/custom_metrics"
+)
+
+// fakePodMetricsInterface stubs resourceclient.PodMetricsInterface, returning a fixed list or
+// error so fleetPodResourceMetric can be exercised without a real metrics.k8s.io API server.
+type fakePodMetricsInterface struct {
+	list *metricsapi.PodMetricsList
+	err  error
+}
+
+func (f *fakePodMetricsInterface) Get(string, metav1.GetOptions) (*metricsapi.PodMetrics, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakePodMetricsInterface) List(metav1.ListOptions) (*metricsapi.PodMetricsList, error) {
+	return f.list, f.err
+}
+
+// fakeNodeMetricsInterface stubs resourceclient.NodeMetricsInterface, which metricsClient never
+// calls, so every method just reports that.
+type fakeNodeMetricsInterface struct{}
+
+func (f *fakeNodeMetricsInterface) Get(string, metav1.GetOptions) (*metricsapi.NodeMetrics, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakeNodeMetricsInterface) List(metav1.ListOptions) (*metricsapi.NodeMetricsList, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+// fakeResourceMetricsClient stubs resourceclient.MetricsV1beta1Interface.
+type fakeResourceMetricsClient struct {
+	pods *fakePodMetricsInterface
+}
+
+func (f *fakeResourceMetricsClient) RESTClient() rest.Interface { return nil }
+
+func (f *fakeResourceMetricsClient) NodeMetricses() resourceclient.NodeMetricsInterface {
+	return &fakeNodeMetricsInterface{}
+}
+
+func (f *fakeResourceMetricsClient) PodMetricses(string) resourceclient.PodMetricsInterface {
+	return f.pods
+}
+
+// fakeNamespacedMetrics stubs the per-namespace half of metricsclient.CustomMetricsClient,
+// returning fixed responses so fleetPodCustomMetric and objectMetric can be exercised without a
+// real custom.metrics.k8s.io API server.
+type fakeNamespacedMetrics struct {
+	objects *custommetricsapi.MetricValue
+	objErr  error
+	forPods *custommetricsapi.MetricValueList
+	podsErr error
+}
+
+func (f *fakeNamespacedMetrics) GetForObject(schema.GroupKind, string, string, labels.Selector) (*custommetricsapi.MetricValue, error) {
+	return f.objects, f.objErr
+}
+
+func (f *fakeNamespacedMetrics) GetForObjects(schema.GroupKind, labels.Selector, string, labels.Selector) (*custommetricsapi.MetricValueList, error) {
+	return f.forPods, f.podsErr
+}
+
+// fakeCustomMetricsClient stubs metricsclient.CustomMetricsClient.
+type fakeCustomMetricsClient struct {
+	namespaced *fakeNamespacedMetrics
+}
+
+func (f *fakeCustomMetricsClient) RootScopedMetrics() metricsclient.MetricsInterface {
+	return f.namespaced
+}
+
+func (f *fakeCustomMetricsClient) NamespacedMetrics(string) metricsclient.MetricsInterface {
+	return f.namespaced
+}
+
+func quantity(v string) resource.Quantity {
+	return resource.MustParse(v)
+}
+
+func fleetWithCPURequest(request string) *v1alpha1.Fleet {
+	f := &v1alpha1.Fleet{ObjectMeta: metav1.ObjectMeta{Name: "fleet", Namespace: "default"}}
+	f.Spec.Template.Spec.Template.Spec.Containers = []corev1.Container{
+		{
+			Name: "gameserver",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: quantity(request)},
+			},
+		},
+	}
+	return f
+}
+
+func TestFleetPodResourceRequests(t *testing.T) {
+	t.Parallel()
+
+	f := fleetWithCPURequest("500m")
+
+	requests, err := fleetPodResourceRequests("cpu", f)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, requests)
+
+	_, err = fleetPodResourceRequests("memory", f)
+	assert.NotNil(t, err, "no memory request is declared, so this must error rather than silently return 0")
+}
+
+func TestMetricsClientDesiredReplicasFromResource(t *testing.T) {
+	t.Parallel()
+
+	t.Run("AverageValue target uses the raw average usage", func(t *testing.T) {
+		m := &metricsClient{resourceMetrics: &fakeResourceMetricsClient{pods: &fakePodMetricsInterface{
+			list: &metricsapi.PodMetricsList{Items: []metricsapi.PodMetrics{
+				{Containers: []metricsapi.ContainerMetrics{{Usage: corev1.ResourceList{corev1.ResourceCPU: quantity("2")}}}},
+			}},
+		}}}
+
+		target := intstr.FromInt(1)
+		source := &v1alpha1.ResourceMetricSource{
+			Name:   "cpu",
+			Target: v1alpha1.MetricTarget{Type: v1alpha1.AverageValueMetricType, AverageValue: &target},
+		}
+
+		desired, err := m.desiredReplicasFromResource(source, fleetWithCPURequest("1"), 2)
+		assert.Nil(t, err)
+		assert.Equal(t, int32(4), desired) // ceil(2 * 2 / 1)
+	})
+
+	t.Run("Utilization target is a percentage of the pod's requested resource, not a raw value", func(t *testing.T) {
+		m := &metricsClient{resourceMetrics: &fakeResourceMetricsClient{pods: &fakePodMetricsInterface{
+			list: &metricsapi.PodMetricsList{Items: []metricsapi.PodMetrics{
+				// 250m used against a 500m request is 50% utilization.
+				{Containers: []metricsapi.ContainerMetrics{{Usage: corev1.ResourceList{corev1.ResourceCPU: quantity("250m")}}}},
+			}},
+		}}}
+
+		target := int32(25)
+		source := &v1alpha1.ResourceMetricSource{
+			Name:   "cpu",
+			Target: v1alpha1.MetricTarget{Type: v1alpha1.UtilizationMetricType, AverageUtilization: &target},
+		}
+
+		desired, err := m.desiredReplicasFromResource(source, fleetWithCPURequest("500m"), 2)
+		assert.Nil(t, err)
+		assert.Equal(t, int32(4), desired) // ceil(2 * 50 / 25), not ceil(2 * 0.25 / 25)
+	})
+}
+
+func TestMetricsClientDesiredReplicasFromPods(t *testing.T) {
+	t.Parallel()
+
+	m := &metricsClient{customMetrics: &fakeCustomMetricsClient{namespaced: &fakeNamespacedMetrics{
+		forPods: &custommetricsapi.MetricValueList{Items: []custommetricsapi.MetricValue{
+			{Value: quantity("10")},
+			{Value: quantity("30")},
+		}},
+	}}}
+
+	target := intstr.FromInt(10)
+	source := &v1alpha1.PodsMetricSource{
+		Metric: "queue_length",
+		Target: v1alpha1.MetricTarget{Type: v1alpha1.AverageValueMetricType, AverageValue: &target},
+	}
+
+	desired, err := m.desiredReplicasFromPods(source, fleetWithCPURequest("500m"), 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(4), desired) // ceil(2 * 20 / 10)
+}
+
+func TestMetricsClientDesiredReplicasFromObject(t *testing.T) {
+	t.Parallel()
+
+	m := &metricsClient{customMetrics: &fakeCustomMetricsClient{namespaced: &fakeNamespacedMetrics{
+		objects: &custommetricsapi.MetricValue{Value: quantity("100")},
+	}}}
+
+	target := intstr.FromInt(50)
+	source := &v1alpha1.ObjectMetricSource{
+		Metric:          "requests_per_second",
+		DescribedObject: v1alpha1.CrossVersionObjectReference{APIVersion: "v1", Kind: "Service", Name: "ingress"},
+		Target:          v1alpha1.MetricTarget{Type: v1alpha1.ValueMetricType, Value: &target},
+	}
+
+	desired, err := m.desiredReplicasFromObject(source, fleetWithCPURequest("500m"), 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(4), desired) // ceil(2 * 100 / 50)
+}
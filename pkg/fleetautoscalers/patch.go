@@ -0,0 +1,55 @@
+// Copyright 2018 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleetautoscalers
+
+import (
+	"encoding/json"
+
+	"agones.dev/agones/pkg/apis/stable/v1alpha1"
+)
+
+// jsonPatchOp is a single RFC 6902 JSON Patch operation. Value is always present: every op this
+// package emits is a "test" or "replace", both of which require it, including an explicit JSON
+// null to clear a field.
+type jsonPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// statusPatch builds the RFC 6902 JSON Patch document used to update a FleetAutoscaler's status
+// subresource, touching only the fields syncFleetAutoscaler recomputes. A `test` op precondition
+// on the previous desiredReplicas is included first, so that a concurrent controller which has
+// already moved desiredReplicas on causes the patch to be rejected, rather than silently
+// clobbering it.
+func statusPatch(previous, status v1alpha1.FleetAutoscalerStatus) ([]byte, error) {
+	ops := []jsonPatchOp{
+		{Op: "test", Path: "/status/desiredReplicas", Value: previous.DesiredReplicas},
+		{Op: "replace", Path: "/status/currentReplicas", Value: status.CurrentReplicas},
+		{Op: "replace", Path: "/status/desiredReplicas", Value: status.DesiredReplicas},
+		{Op: "replace", Path: "/status/ableToScale", Value: status.AbleToScale},
+		{Op: "replace", Path: "/status/scalingLimited", Value: status.ScalingLimited},
+	}
+
+	// Always emit the op, even when clearing lastScaleTime back to nil: appending it only when
+	// non-nil would silently drop that clear, since there would then be no op to apply it.
+	var lastScaleTime interface{}
+	if status.LastScaleTime != nil {
+		lastScaleTime = status.LastScaleTime
+	}
+	ops = append(ops, jsonPatchOp{Op: "replace", Path: "/status/lastScaleTime", Value: lastScaleTime})
+
+	return json.Marshal(ops)
+}
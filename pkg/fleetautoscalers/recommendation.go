@@ -0,0 +1,235 @@
+// Copyright 2018 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleetautoscalers
+
+import (
+	"sync"
+	"time"
+
+	"agones.dev/agones/pkg/apis/stable/v1alpha1"
+)
+
+// timestampedRecommendation is a replica recommendation made at a point in time, kept around so
+// that a stabilization window can look back over recent history.
+type timestampedRecommendation struct {
+	replicas int32
+	time     time.Time
+}
+
+// maxScalingPolicyPeriod is the largest PeriodSeconds a FleetAutoscalerScalingPolicy can declare
+// (see FleetAutoscaler.Validate), and so bounds how long rateLimit needs to retain past scale
+// events for: one older than this can no longer count against any policy's budget.
+const maxScalingPolicyPeriod = 1800 * time.Second
+
+// scaleEvent records a single rate-limited change actually applied to a Fleet's replica count, so
+// rateLimit can sum how much of a policy's Value has already been spent within its trailing
+// PeriodSeconds window. delta is signed: positive for a scale up, negative for a scale down.
+type scaleEvent struct {
+	delta int32
+	time  time.Time
+}
+
+// recommendationStore retains a rolling window of past replica recommendations per
+// FleetAutoscaler, keyed by namespace/name, so that Behavior.ScaleUp/ScaleDown stabilization
+// windows can be evaluated without needing to persist history on the FleetAutoscaler itself.
+type recommendationStore struct {
+	lock            sync.Mutex
+	recommendations map[string][]timestampedRecommendation
+	// scaleEvents records, per FleetAutoscaler, every rate-limited change actually applied within
+	// the last maxScalingPolicyPeriod, so rateLimit measures the rate of change actually achieved
+	// rather than resetting its clock on every sync (which, since scaling a Fleet immediately
+	// requeues its FleetAutoscaler, would otherwise see PeriodSeconds' worth of allowance as
+	// available on almost every tick).
+	scaleEvents map[string][]scaleEvent
+}
+
+func newRecommendationStore() *recommendationStore {
+	return &recommendationStore{
+		recommendations: map[string][]timestampedRecommendation{},
+		scaleEvents:     map[string][]scaleEvent{},
+	}
+}
+
+// stabilize records the new recommendation for key at now, prunes entries older than window out
+// of the rolling history, and returns the stabilized recommendation: the max of the window's
+// recommendations when recommending a scale down, or the min when recommending a scale up. This
+// mirrors the suppression HPA v2 applies via StabilizationWindowSeconds.
+func (s *recommendationStore) stabilize(key string, desiredReplicas, currentReplicas int32, window time.Duration, now time.Time) int32 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	history := append(s.recommendations[key], timestampedRecommendation{replicas: desiredReplicas, time: now})
+
+	cutoff := now.Add(-window)
+	kept := history[:0]
+	for _, r := range history {
+		if !r.time.Before(cutoff) {
+			kept = append(kept, r)
+		}
+	}
+	s.recommendations[key] = kept
+
+	if desiredReplicas < currentReplicas {
+		// scaling down: prefer the largest recent recommendation, to avoid scaling down on a
+		// transient dip
+		stabilized := desiredReplicas
+		for _, r := range kept {
+			if r.replicas > stabilized {
+				stabilized = r.replicas
+			}
+		}
+		return stabilized
+	}
+
+	// scaling up (or no change): prefer the smallest recent recommendation, to avoid scaling up
+	// on a transient spike
+	stabilized := desiredReplicas
+	for _, r := range kept {
+		if r.replicas < stabilized {
+			stabilized = r.replicas
+		}
+	}
+	return stabilized
+}
+
+// rateLimit caps the delta between currentReplicas and desiredReplicas according to the scaling
+// rules that apply for the direction of the change, returning the capped replica count and
+// whether it was limited.
+func (s *recommendationStore) rateLimit(key string, rules *v1alpha1.FleetAutoscalerScalingRules, currentReplicas, desiredReplicas int32, now time.Time) (int32, bool) {
+	if rules == nil {
+		return desiredReplicas, false
+	}
+
+	if desiredReplicas == currentReplicas {
+		// nothing to rate limit: neither the scale-up nor scale-down candidate formula applies
+		// when there is no direction of change
+		return desiredReplicas, false
+	}
+
+	if rules.SelectPolicy != nil && *rules.SelectPolicy == v1alpha1.DisabledScalingPolicySelect {
+		return currentReplicas, true
+	}
+
+	if len(rules.Policies) == 0 {
+		return desiredReplicas, false
+	}
+
+	scalingUp := desiredReplicas > currentReplicas
+
+	s.lock.Lock()
+	events := s.scaleEvents[key]
+	s.lock.Unlock()
+
+	candidates := make([]int32, 0, len(rules.Policies))
+	for _, p := range rules.Policies {
+		period := time.Duration(p.PeriodSeconds) * time.Second
+		cutoff := now.Add(-period)
+
+		// how much of this policy's allowance the events already applied within its trailing
+		// PeriodSeconds window have used up, so repeated ticks within the same window converge
+		// toward the configured rate instead of each tick independently re-applying the policy's
+		// full allowance.
+		var spent int32
+		for _, e := range events {
+			if e.time.Before(cutoff) {
+				continue
+			}
+			if scalingUp && e.delta > 0 {
+				spent += e.delta
+			} else if !scalingUp && e.delta < 0 {
+				spent += -e.delta
+			}
+		}
+
+		var allowance int32
+		switch p.Type {
+		case v1alpha1.PodsScalingPolicy:
+			allowance = p.Value
+		case v1alpha1.PercentScalingPolicy:
+			allowance = int32(float64(currentReplicas) * float64(p.Value) / 100)
+		}
+		// a percentage of a small currentReplicas can round down to 0; always allow at least 1
+		// replica of headroom per policy, as Kubernetes HPA v2 does.
+		if allowance < 1 {
+			allowance = 1
+		}
+
+		// once this period's allowance is fully spent, no further change is allowed until older
+		// events age out of the trailing window - unlike allowance, this is not floored to 1,
+		// since doing so would let dense ticks creep forward by a replica apiece forever.
+		delta := allowance - spent
+		if delta < 0 {
+			delta = 0
+		}
+
+		if scalingUp {
+			candidates = append(candidates, currentReplicas+delta)
+		} else {
+			candidates = append(candidates, currentReplicas-delta)
+		}
+	}
+
+	selectPolicy := v1alpha1.MaxScalingPolicySelect
+	if rules.SelectPolicy != nil {
+		selectPolicy = *rules.SelectPolicy
+	}
+
+	limited := candidates[0]
+	for _, c := range candidates[1:] {
+		switch selectPolicy {
+		case v1alpha1.MinScalingPolicySelect:
+			if scalingUp && c < limited || !scalingUp && c > limited {
+				limited = c
+			}
+		default: // Max
+			if scalingUp && c > limited || !scalingUp && c < limited {
+				limited = c
+			}
+		}
+	}
+
+	if scalingUp && limited > desiredReplicas {
+		limited = desiredReplicas
+	}
+	if !scalingUp && limited < desiredReplicas {
+		limited = desiredReplicas
+	}
+
+	return limited, limited != desiredReplicas
+}
+
+// recordScale remembers a rate-limited change actually applied to the Fleet's replica count, so
+// that subsequent rateLimit calls can measure the rate of change actually achieved within each
+// policy's trailing PeriodSeconds window. It is a no-op if the Fleet's replica count did not
+// change.
+func (s *recommendationStore) recordScale(key string, previousReplicas, appliedReplicas int32, now time.Time) {
+	delta := appliedReplicas - previousReplicas
+	if delta == 0 {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	cutoff := now.Add(-maxScalingPolicyPeriod)
+	events := append(s.scaleEvents[key], scaleEvent{delta: delta, time: now})
+	kept := events[:0]
+	for _, e := range events {
+		if !e.time.Before(cutoff) {
+			kept = append(kept, e)
+		}
+	}
+	s.scaleEvents[key] = kept
+}
@@ -0,0 +1,144 @@
+// Copyright 2018 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleetautoscalers
+
+import (
+	"time"
+
+	"agones.dev/agones/pkg/apis/stable/v1alpha1"
+	"github.com/robfig/cron/v3"
+)
+
+// cronParser parses the standard 5-field cron expressions used by ScheduleEntry.Cron
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+// scheduleLookback bounds how far back lastActivation searches for an entry's most recent
+// activation, so that a sparse schedule (e.g. once a year) can't cause an unbounded scan.
+const scheduleLookback = 366 * 24 * time.Hour
+
+// entryLocation returns the time.Location an entry's Cron expression should be evaluated in,
+// defaulting to UTC.
+func entryLocation(entry v1alpha1.ScheduleEntry) (*time.Location, error) {
+	if entry.TimeZone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(entry.TimeZone)
+}
+
+// lastActivation returns the most recent time at or before now at which spec would have fired,
+// in the given location, or the zero time if it did not fire within scheduleLookback of now.
+//
+// It first finds a starting point close to the last activation by doubling a search window
+// backwards from now until an activation falls inside it, rather than scanning one activation
+// at a time from now-scheduleLookback — which would run, e.g., ~527,000 Next() calls per tick
+// for an every-minute cron. It then steps forward from that point, which costs only as many
+// Next() calls as there are activations within the (small) found window.
+func lastActivation(spec string, loc *time.Location, now time.Time) (time.Time, error) {
+	sched, err := cronParser.Parse(spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	nowInLoc := now.In(loc)
+
+	var from time.Time
+	found := false
+	for step := time.Minute; ; step *= 2 {
+		if step > scheduleLookback {
+			step = scheduleLookback
+		}
+		from = nowInLoc.Add(-step)
+		if next := sched.Next(from); !next.IsZero() && !next.After(nowInLoc) {
+			found = true
+			break
+		}
+		if step == scheduleLookback {
+			break
+		}
+	}
+	if !found {
+		return time.Time{}, nil
+	}
+
+	var last time.Time
+	for cursor := from; ; {
+		next := sched.Next(cursor)
+		if next.IsZero() || next.After(nowInLoc) {
+			return last, nil
+		}
+		last = next
+		cursor = next
+	}
+}
+
+// activeEntry returns the first ScheduleEntry in policy whose window currently contains now, the
+// time at which that window closes, and true — or ok=false if no entry is currently active.
+func activeEntry(policy *v1alpha1.SchedulePolicy, now time.Time) (entry *v1alpha1.ScheduleEntry, windowEnd time.Time, ok bool, err error) {
+	for i := range policy.Entries {
+		e := &policy.Entries[i]
+
+		loc, err := entryLocation(*e)
+		if err != nil {
+			return nil, time.Time{}, false, err
+		}
+
+		last, err := lastActivation(e.Cron, loc, now)
+		if err != nil {
+			return nil, time.Time{}, false, err
+		}
+		if last.IsZero() {
+			continue
+		}
+
+		end := last.Add(e.Duration.Duration)
+		if now.Before(end) {
+			return e, end, true, nil
+		}
+	}
+
+	return nil, time.Time{}, false, nil
+}
+
+// nextBoundary returns the next time at which some ScheduleEntry's window will open or close, so
+// the controller can requeue the FleetAutoscaler to re-evaluate its policy at that moment, even
+// without an external trigger.
+func nextBoundary(policy *v1alpha1.SchedulePolicy, now time.Time) (time.Time, error) {
+	var next time.Time
+
+	if _, end, ok, err := activeEntry(policy, now); err != nil {
+		return time.Time{}, err
+	} else if ok {
+		next = end
+	}
+
+	for _, e := range policy.Entries {
+		loc, err := entryLocation(e)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		sched, err := cronParser.Parse(e.Cron)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		n := sched.Next(now.In(loc))
+		if next.IsZero() || n.Before(next) {
+			next = n
+		}
+	}
+
+	return next, nil
+}
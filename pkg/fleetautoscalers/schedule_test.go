@@ -0,0 +1,270 @@
+// Copyright 2018 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleetautoscalers
+
+import (
+	"testing"
+	"time"
+
+	"agones.dev/agones/pkg/apis/stable/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestLastActivation(t *testing.T) {
+	t.Parallel()
+
+	t.Run("now is exactly at an activation's boundary", func(t *testing.T) {
+		t.Parallel()
+		now := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+		last, err := lastActivation("0 9 * * *", time.UTC, now)
+		assert.Nil(t, err)
+		assert.Equal(t, now, last)
+	})
+
+	t.Run("no activation within scheduleLookback", func(t *testing.T) {
+		t.Parallel()
+		// fires only on leap days; the last one before 2023-03-01 was 2020-02-29, well more
+		// than scheduleLookback (366 days) earlier.
+		now := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+		last, err := lastActivation("0 0 29 2 *", time.UTC, now)
+		assert.Nil(t, err)
+		assert.True(t, last.IsZero(), "expected no activation to be found within scheduleLookback")
+	})
+
+	t.Run("invalid cron expression", func(t *testing.T) {
+		t.Parallel()
+		_, err := lastActivation("not a cron string", time.UTC, time.Now())
+		assert.NotNil(t, err)
+	})
+}
+
+func scheduleEntry(cron, timeZone string, duration time.Duration, min, max int32) v1alpha1.ScheduleEntry {
+	return v1alpha1.ScheduleEntry{
+		Cron:        cron,
+		TimeZone:    timeZone,
+		Duration:    metav1.Duration{Duration: duration},
+		MinReplicas: min,
+		MaxReplicas: max,
+	}
+}
+
+func TestActiveEntry(t *testing.T) {
+	t.Parallel()
+
+	t.Run("now inside the window", func(t *testing.T) {
+		t.Parallel()
+		now := time.Date(2024, time.January, 1, 9, 30, 0, 0, time.UTC)
+		policy := &v1alpha1.SchedulePolicy{
+			Entries: []v1alpha1.ScheduleEntry{scheduleEntry("0 9 * * *", "", time.Hour, 10, 50)},
+		}
+
+		entry, end, ok, err := activeEntry(policy, now)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, &policy.Entries[0], entry)
+		assert.Equal(t, time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC), end)
+	})
+
+	t.Run("now exactly at the window's start", func(t *testing.T) {
+		t.Parallel()
+		now := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+		policy := &v1alpha1.SchedulePolicy{
+			Entries: []v1alpha1.ScheduleEntry{scheduleEntry("0 9 * * *", "", time.Hour, 10, 50)},
+		}
+
+		_, _, ok, err := activeEntry(policy, now)
+		assert.Nil(t, err)
+		assert.True(t, ok, "the window should be active at its own start boundary")
+	})
+
+	t.Run("now exactly at the window's end", func(t *testing.T) {
+		t.Parallel()
+		now := time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC)
+		policy := &v1alpha1.SchedulePolicy{
+			Entries: []v1alpha1.ScheduleEntry{scheduleEntry("0 9 * * *", "", time.Hour, 10, 50)},
+		}
+
+		_, _, ok, err := activeEntry(policy, now)
+		assert.Nil(t, err)
+		assert.False(t, ok, "the window should be half-open, excluding its end boundary")
+	})
+
+	t.Run("first matching entry wins when windows overlap", func(t *testing.T) {
+		t.Parallel()
+		now := time.Date(2024, time.January, 1, 9, 30, 0, 0, time.UTC)
+		policy := &v1alpha1.SchedulePolicy{
+			Entries: []v1alpha1.ScheduleEntry{
+				scheduleEntry("0 9 * * *", "", 2*time.Hour, 10, 50),
+				scheduleEntry("0 9 * * *", "", 2*time.Hour, 100, 200),
+			},
+		}
+
+		entry, _, ok, err := activeEntry(policy, now)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, &policy.Entries[0], entry)
+	})
+
+	t.Run("no entry active", func(t *testing.T) {
+		t.Parallel()
+		now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+		policy := &v1alpha1.SchedulePolicy{
+			Entries: []v1alpha1.ScheduleEntry{scheduleEntry("0 9 * * *", "", time.Hour, 10, 50)},
+		}
+
+		_, _, ok, err := activeEntry(policy, now)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestApplySchedulePolicyFallback(t *testing.T) {
+	t.Parallel()
+
+	c, _ := newFakeController()
+
+	t.Run("active entry with a nested FallbackPolicy, clamped to the entry's bounds", func(t *testing.T) {
+		t.Parallel()
+		f := &v1alpha1.Fleet{Status: v1alpha1.FleetStatus{Replicas: 5, AllocatedReplicas: 5}}
+		policy := &v1alpha1.SchedulePolicy{
+			Entries: []v1alpha1.ScheduleEntry{
+				{
+					Cron:        "* * * * *",
+					Duration:    metav1.Duration{Duration: 24 * time.Hour},
+					MinReplicas: 1,
+					MaxReplicas: 20,
+					FallbackPolicy: &v1alpha1.FleetAutoscalerPolicy{
+						Type:   v1alpha1.BufferPolicyType,
+						Buffer: &v1alpha1.BufferPolicy{BufferSize: intstr.FromInt(10), MinReplicas: 1, MaxReplicas: 1000},
+					},
+				},
+			},
+		}
+
+		desired, limited, err := c.applySchedulePolicy(policy, f)
+		assert.Nil(t, err)
+		assert.Equal(t, int32(15), desired) // allocated 5 + buffer 10, within [1, 20]
+		assert.False(t, limited)
+	})
+
+	t.Run("active entry's FallbackPolicy recommendation is clamped to MinReplicas/MaxReplicas", func(t *testing.T) {
+		t.Parallel()
+		f := &v1alpha1.Fleet{Status: v1alpha1.FleetStatus{Replicas: 5, AllocatedReplicas: 5}}
+		policy := &v1alpha1.SchedulePolicy{
+			Entries: []v1alpha1.ScheduleEntry{
+				{
+					Cron:        "* * * * *",
+					Duration:    metav1.Duration{Duration: 24 * time.Hour},
+					MinReplicas: 50,
+					MaxReplicas: 100,
+					FallbackPolicy: &v1alpha1.FleetAutoscalerPolicy{
+						Type:   v1alpha1.BufferPolicyType,
+						Buffer: &v1alpha1.BufferPolicy{BufferSize: intstr.FromInt(10), MinReplicas: 1, MaxReplicas: 1000},
+					},
+				},
+			},
+		}
+
+		desired, limited, err := c.applySchedulePolicy(policy, f)
+		assert.Nil(t, err)
+		assert.Equal(t, int32(50), desired) // allocated 5 + buffer 10 = 15, clamped up to MinReplicas
+		assert.True(t, limited)
+	})
+
+	t.Run("no entry active falls back to the SchedulePolicy's own FallbackPolicy", func(t *testing.T) {
+		t.Parallel()
+		f := &v1alpha1.Fleet{Status: v1alpha1.FleetStatus{Replicas: 5, AllocatedReplicas: 5}}
+		policy := &v1alpha1.SchedulePolicy{
+			// a leap-day-only cron is, for the practical duration of a test run, never active.
+			Entries: []v1alpha1.ScheduleEntry{scheduleEntry("0 0 29 2 *", "", time.Hour, 10, 50)},
+			FallbackPolicy: &v1alpha1.FleetAutoscalerPolicy{
+				Type:   v1alpha1.BufferPolicyType,
+				Buffer: &v1alpha1.BufferPolicy{BufferSize: intstr.FromInt(3), MinReplicas: 1, MaxReplicas: 1000},
+			},
+		}
+
+		desired, limited, err := c.applySchedulePolicy(policy, f)
+		assert.Nil(t, err)
+		assert.Equal(t, int32(8), desired) // allocated 5 + buffer 3, since no entry's window is active
+		assert.False(t, limited)
+	})
+
+	t.Run("no entry active and no SchedulePolicy FallbackPolicy keeps the Fleet's current replicas", func(t *testing.T) {
+		t.Parallel()
+		f := &v1alpha1.Fleet{Status: v1alpha1.FleetStatus{Replicas: 42}}
+		policy := &v1alpha1.SchedulePolicy{
+			Entries: []v1alpha1.ScheduleEntry{scheduleEntry("0 0 29 2 *", "", time.Hour, 10, 50)},
+		}
+
+		desired, limited, err := c.applySchedulePolicy(policy, f)
+		assert.Nil(t, err)
+		assert.Equal(t, int32(42), desired)
+		assert.False(t, limited)
+	})
+}
+
+func TestNextBoundary(t *testing.T) {
+	t.Parallel()
+
+	t.Run("inside an active window, the next boundary is the window's end", func(t *testing.T) {
+		t.Parallel()
+		now := time.Date(2024, time.January, 1, 9, 30, 0, 0, time.UTC)
+		policy := &v1alpha1.SchedulePolicy{
+			Entries: []v1alpha1.ScheduleEntry{scheduleEntry("0 9 * * *", "", time.Hour, 10, 50)},
+		}
+
+		next, err := nextBoundary(policy, now)
+		assert.Nil(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC), next)
+	})
+
+	t.Run("outside any window, the next boundary is the nearest future activation", func(t *testing.T) {
+		t.Parallel()
+		now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+		policy := &v1alpha1.SchedulePolicy{
+			Entries: []v1alpha1.ScheduleEntry{
+				scheduleEntry("0 9 * * *", "", time.Hour, 10, 50),  // next: Jan 2, 09:00
+				scheduleEntry("0 18 * * *", "", time.Hour, 10, 50), // next: Jan 1, 18:00 - nearer
+			},
+		}
+
+		next, err := nextBoundary(policy, now)
+		assert.Nil(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 1, 18, 0, 0, 0, time.UTC), next)
+	})
+
+	t.Run("honors each entry's own TimeZone across a DST transition", func(t *testing.T) {
+		t.Parallel()
+		loc, err := time.LoadLocation("America/New_York")
+		assert.Nil(t, err)
+
+		// clocks in America/New_York spring forward from 02:00 to 03:00 EST on 2024-03-10;
+		// a 9am local fire on that day is already in EDT (UTC-4), not EST (UTC-5). now is set
+		// to just after the previous day's 9am-10am window closes, so the next fire is Mar 10.
+		now := time.Date(2024, time.March, 9, 16, 0, 0, 0, time.UTC)
+		policy := &v1alpha1.SchedulePolicy{
+			Entries: []v1alpha1.ScheduleEntry{scheduleEntry("0 9 * * *", "America/New_York", time.Hour, 10, 50)},
+		}
+
+		next, err := nextBoundary(policy, now)
+		assert.Nil(t, err)
+		assert.Equal(t, time.Date(2024, time.March, 10, 9, 0, 0, 0, loc).UTC(), next.UTC())
+		assert.Equal(t, time.Date(2024, time.March, 10, 13, 0, 0, 0, time.UTC), next.UTC())
+	})
+}